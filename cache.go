@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry 缓存的一条结果，Value 为 JSON 编码后的数据
+type CacheEntry struct {
+	Value    []byte
+	StoredAt time.Time
+}
+
+// Cache 缓存后端，默认实现为 LRUCache，也可以对接 Redis 等外部存储
+// 实现需要是并发安全的，后端出错时 Get 应当返回未命中，Set 和 Delete 可以忽略错误
+type Cache interface {
+	Get(ctx context.Context, key string) (*CacheEntry, bool)
+	// Set 保存结果，ttl 为 CachePolicy 中 TTL 与 StaleTTL 之和，超过后可以删除
+	Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration)
+	Delete(ctx context.Context, key string)
+}
+
+// CachePolicy 一个操作的缓存策略
+type CachePolicy struct {
+	TTL        time.Duration // 结果在 TTL 内直接返回，不访问教务处
+	StaleTTL   time.Duration // 过期后的 StaleTTL 内，刷新失败时返回旧数据并标记为 Stale
+	Background bool          // 过期后立即返回旧数据并在后台刷新（stale-while-revalidate）
+	PerStudent bool          // 缓存 key 包含学号，不同学生之间不共享结果
+}
+
+// DefaultCachePolicies 默认缓存的操作，都是与学生无关、变化不频繁的数据
+func DefaultCachePolicies() map[string]CachePolicy {
+	return map[string]CachePolicy{
+		"GetSchoolCalendar":  {TTL: 24 * time.Hour, StaleTTL: 7 * 24 * time.Hour},
+		"GetTermEvents":      {TTL: 24 * time.Hour, StaleTTL: 7 * 24 * time.Hour},
+		"GetNoticeInfo":      {TTL: 10 * time.Minute, StaleTTL: 24 * time.Hour},
+		"GetNoticeDetail":    {TTL: 24 * time.Hour, StaleTTL: 7 * 24 * time.Hour},
+		"GetLocateDate":      {TTL: time.Hour, StaleTTL: 24 * time.Hour},
+		"GetEmptyRoom":       {TTL: 10 * time.Minute, StaleTTL: time.Hour},
+		"GetQiShanEmptyRoom": {TTL: 10 * time.Minute, StaleTTL: time.Hour},
+	}
+}
+
+// CacheStatus 一次调用的缓存状态，通过 ContextWithCacheStatus 获取
+type CacheStatus struct {
+	Hit      bool      // 结果来自缓存
+	Stale    bool      // 结果已经过期，是刷新失败或后台刷新时返回的旧数据
+	StoredAt time.Time // 结果写入缓存的时间
+	Err      error     // 刷新失败的原因
+}
+
+type cacheStatusKey struct{}
+
+// ContextWithCacheStatus 返回的 ctx 用于调用 Student 的方法，调用结束后通过 *CacheStatus 获取缓存状态
+func ContextWithCacheStatus(ctx context.Context) (context.Context, *CacheStatus) {
+	status := &CacheStatus{}
+	return context.WithValue(ctx, cacheStatusKey{}, status), status
+}
+
+type noCacheKey struct{}
+
+// ContextWithoutCache 返回的 ctx 会跳过缓存直接访问教务处，结果仍会写入缓存
+func ContextWithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// studentCache Student 上的缓存配置
+type studentCache struct {
+	cache    Cache
+	policies map[string]CachePolicy
+
+	mu       sync.Mutex
+	inflight map[string]bool // 正在后台刷新的 key
+}
+
+func newStudentCache(cache Cache, policies map[string]CachePolicy) *studentCache {
+	if policies == nil {
+		policies = DefaultCachePolicies()
+	}
+	return &studentCache{cache: cache, policies: maps.Clone(policies), inflight: map[string]bool{}}
+}
+
+// cacheKey 生成缓存 key，共享数据与学生数据使用不同的前缀
+func (s *Student) cacheKey(op string, policy CachePolicy, args []string) string {
+	scope := "shared"
+	if policy.PerStudent {
+		scope = "student:" + s.ID
+	}
+	return "jwch:" + op + ":" + scope + ":" + strings.Join(args, "|")
+}
+
+// cached 按 ctx 中的操作名查找缓存策略，命中时直接返回缓存，否则调用 fetch 并写入缓存
+// 没有配置缓存或该操作没有缓存策略时直接调用 fetch
+func cached[T any](ctx context.Context, s *Student, args []string, fetch func(context.Context) (T, error)) (T, error) {
+	c := s.cache
+	if c == nil {
+		return fetch(ctx)
+	}
+	op := OperationFromContext(ctx)
+	policy, ok := c.policies[op]
+	if !ok || policy.TTL <= 0 {
+		return fetch(ctx)
+	}
+	key := s.cacheKey(op, policy, args)
+	status, _ := ctx.Value(cacheStatusKey{}).(*CacheStatus)
+	if status == nil {
+		status = &CacheStatus{}
+	}
+
+	var stale T
+	var staleEntry *CacheEntry
+	if bypass, _ := ctx.Value(noCacheKey{}).(bool); !bypass {
+		if entry, ok := c.cache.Get(ctx, key); ok {
+			var value T
+			age := time.Since(entry.StoredAt)
+			switch {
+			case json.Unmarshal(entry.Value, &value) != nil:
+				c.cache.Delete(ctx, key)
+			case age < policy.TTL:
+				*status = CacheStatus{Hit: true, StoredAt: entry.StoredAt}
+				return value, nil
+			case age < policy.TTL+policy.StaleTTL:
+				stale, staleEntry = value, entry
+			}
+		}
+	}
+
+	if staleEntry != nil && policy.Background {
+		*status = CacheStatus{Hit: true, Stale: true, StoredAt: staleEntry.StoredAt}
+		revalidate(ctx, s, key, policy, fetch)
+		return stale, nil
+	}
+
+	value, err := fetch(ctx)
+	if err == nil {
+		c.store(ctx, key, policy, value)
+		*status = CacheStatus{}
+		return value, nil
+	}
+	// 调用方取消时不返回旧数据
+	if staleEntry == nil || ClassifyOutcome(err) == OutcomeCanceled {
+		return value, err
+	}
+	s.logger.WarnContext(ctx, "jwch: serving stale cache", "op", op, "stored_at", staleEntry.StoredAt, "error", err)
+	*status = CacheStatus{Hit: true, Stale: true, StoredAt: staleEntry.StoredAt, Err: err}
+	return stale, nil
+}
+
+func (c *studentCache) store(ctx context.Context, key string, policy CachePolicy, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	c.cache.Set(ctx, key, &CacheEntry{Value: data, StoredAt: time.Now()}, policy.TTL+policy.StaleTTL)
+}
+
+// revalidate 在后台刷新 key，同一个 key 同时只有一个刷新
+func revalidate[T any](ctx context.Context, s *Student, key string, policy CachePolicy, fetch func(context.Context) (T, error)) {
+	c := s.cache
+	c.mu.Lock()
+	if c.inflight[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.inflight[key] = true
+	c.mu.Unlock()
+
+	// 后台刷新不受调用方取消的影响，但保留操作名等信息
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.inflight, key)
+			c.mu.Unlock()
+		}()
+		value, err := fetch(ctx)
+		if err != nil {
+			s.logger.WarnContext(ctx, "jwch: cache revalidation failed", "op", OperationFromContext(ctx), "error", err)
+			return
+		}
+		c.store(ctx, key, policy, value)
+	}()
+}
+
+// LRUCache 内存中的 LRU 缓存，容量满时淘汰最久未使用的结果
+type LRUCache struct {
+	capacity int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type lruItem struct {
+	key       string
+	entry     *CacheEntry
+	expiresAt time.Time
+}
+
+// NewLRUCache 创建最多保存 capacity 条结果的 LRUCache，capacity <= 0 时不限制
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{capacity: capacity, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+func (c *LRUCache) Get(ctx context.Context, key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := el.Value.(*lruItem)
+	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return item.entry, true
+}
+
+func (c *LRUCache) Set(ctx context.Context, key string, entry *CacheEntry, ttl time.Duration) {
+	item := &lruItem{key: key, entry: entry}
+	if ttl > 0 {
+		item.expiresAt = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value = item
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(item)
+	for c.capacity > 0 && c.ll.Len() > c.capacity {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *LRUCache) Delete(ctx context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+// Len 返回当前保存的结果数
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRUCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*lruItem).key)
+}
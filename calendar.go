@@ -17,141 +17,50 @@ limitations under the License.
 package jwch
 
 import (
-	"fmt"
-	"regexp"
-	"strings"
-
-	"github.com/west2-online/jwch/utils"
-
-	"github.com/antchfx/htmlquery"
-
-	"github.com/west2-online/jwch/constants"
+	"context"
+	"time"
 )
 
 func (s *Student) GetSchoolCalendar() (*SchoolCalendar, error) {
-	resp, err := s.GetWithIdentifier(constants.SchoolCalendarURL)
-	if err != nil {
-		return nil, err
-	}
+	return s.GetSchoolCalendarCtx(context.Background())
+}
 
-	curTermNode := htmlquery.FindOne(resp, `//html/body/center/div`)
-	if curTermNode == nil {
-		return nil, fmt.Errorf("failed to find current term node")
-	}
+// GetSchoolCalendarCtx 同 GetSchoolCalendar，支持通过 ctx 取消请求
+func (s *Student) GetSchoolCalendarCtx(ctx context.Context) (_ *SchoolCalendar, err error) {
+	ctx = ContextWithOperation(ctx, "GetSchoolCalendar")
+	defer s.finish(ctx, time.Now(), &err)
+	return cached(ctx, s, nil, s.getSchoolCalendar)
+}
 
-	rawCurTerm := htmlquery.InnerText(curTermNode)
-	rawCurTerm, err = utils.ConvertGB2312ToUTF8([]byte(rawCurTerm))
+func (s *Student) getSchoolCalendar(ctx context.Context) (*SchoolCalendar, error) {
+	resp, err := s.GetWithIdentifierCtx(ctx, s.endpoints.SchoolCalendarURL)
 	if err != nil {
 		return nil, err
 	}
-	curTermRegex := regexp.MustCompile(`当前学期：(\d{6})`)
-	curTermMatch := curTermRegex.FindStringSubmatch(rawCurTerm)
-	if len(curTermMatch) < 2 {
-		return nil, fmt.Errorf("failed to parse current term from school calendar page")
-	}
-	curTerm := curTermMatch[1]
-
-	res := &SchoolCalendar{
-		CurrentTerm: curTerm,
-	}
 
-	list := htmlquery.Find(resp, `//select[@name="xq"]/option/@value`)
-
-	for _, node := range list {
-		// 需要取前16个年份
-		if len(res.Terms) >= 16 {
-			break
-		}
-		rawTerm := htmlquery.SelectAttr(node, "value")
-		if len(rawTerm) < 22 {
-			continue
-		}
-		/*
-			2024012024082620250117
-			[0] 202401
-			[1] 20240826
-			[2] 20250117
-		*/
-		schoolYear := rawTerm[0:4]
-		term := rawTerm[0:6]
-		startDate := rawTerm[6:14]
-		endDate := rawTerm[14:22]
-
-		// convert 20240826 to 2024-08-26
-		startDate = startDate[0:4] + "-" + startDate[4:6] + "-" + startDate[6:8]
-		endDate = endDate[0:4] + "-" + endDate[4:6] + "-" + endDate[6:8]
+	return s.parser().SchoolCalendar(resp)
+}
 
-		res.Terms = append(res.Terms, CalTerm{
-			TermId:     rawTerm,
-			SchoolYear: schoolYear,
-			Term:       term,
-			StartDate:  startDate,
-			EndDate:    endDate,
-		})
-	}
+func (s *Student) GetTermEvents(termId string) (*CalTermEvents, error) {
+	return s.GetTermEventsCtx(context.Background(), termId)
+}
 
-	return res, nil
+// GetTermEventsCtx 同 GetTermEvents，支持通过 ctx 取消请求
+func (s *Student) GetTermEventsCtx(ctx context.Context, termId string) (_ *CalTermEvents, err error) {
+	ctx = ContextWithOperation(ctx, "GetTermEvents")
+	defer s.finish(ctx, time.Now(), &err)
+	return cached(ctx, s, []string{termId}, func(ctx context.Context) (*CalTermEvents, error) {
+		return s.getTermEvents(ctx, termId)
+	})
 }
 
-func (s *Student) GetTermEvents(termId string) (*CalTermEvents, error) {
-	resp, err := s.PostWithIdentifier(constants.SchoolCalendarURL, map[string]string{
+func (s *Student) getTermEvents(ctx context.Context, termId string) (*CalTermEvents, error) {
+	resp, err := s.PostWithIdentifierCtx(ctx, s.endpoints.SchoolCalendarURL, map[string]string{
 		"xq":     termId,
 		"submit": "提交",
 	})
 	if err != nil {
 		return nil, err
 	}
-	res := &CalTermEvents{
-		TermId:     termId,
-		Term:       termId[0:6],
-		SchoolYear: termId[0:4],
-	}
-	// 远古校历没有任何内容
-	table := htmlquery.FindOne(resp, `/html/body/table[2]/tbody/tr`)
-	if table == nil {
-		return res, nil
-	}
-	rawTermDetail := htmlquery.InnerText(table)
-	rawTermDetail = strings.ReplaceAll(rawTermDetail, " ", " ")
-	rawTermDetail, _ = utils.ConvertGB2312ToUTF8([]byte(rawTermDetail))
-
-	termDetail := strings.Split(rawTermDetail, "；")
-
-	for _, event := range termDetail {
-		event = strings.TrimSpace(event)
-		if event == "" {
-			continue
-		}
-
-		rawData := strings.Split(event, "为")
-		if len(rawData) < 2 {
-			// 远古学期的数据格式可能不统一，不做处理
-			res.Events = append(res.Events, CalTermEvent{Name: strings.TrimSpace(event)})
-			continue
-		}
-
-		rawDate := strings.Split(strings.TrimSpace(rawData[0]), "至")
-		name := strings.TrimSpace(strings.Join(rawData[1:], "为"))
-
-		if len(rawDate) >= 2 {
-			startDate := strings.TrimSpace(rawDate[0])
-			endDate := strings.TrimSpace(rawDate[1])
-
-			res.Events = append(res.Events, CalTermEvent{
-				Name:      name,
-				StartDate: startDate,
-				EndDate:   endDate,
-			})
-		} else {
-			// 兼容单日事件格式: 2025-09-07为学生注册
-			date := strings.TrimSpace(rawDate[0])
-			res.Events = append(res.Events, CalTermEvent{
-				Name:      name,
-				StartDate: date,
-				EndDate:   date,
-			})
-		}
-	}
-
-	return res, nil
+	return s.parser().TermEvents(resp, termId)
 }
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/west2-online/jwch/constants"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/utils"
+)
+
+// 验证码被拒绝时，登录流程默认重新获取验证码的次数
+const defaultCaptchaAttempts = 3
+
+// CaptchaSolver 验证码识别器
+// image 为从 VerifyCodeURL 获取到的原始图片字节，返回识别出的验证码
+type CaptchaSolver interface {
+	Solve(ctx context.Context, image []byte) (string, error)
+}
+
+// CaptchaSolverFunc 将普通函数适配为 CaptchaSolver
+type CaptchaSolverFunc func(ctx context.Context, image []byte) (string, error)
+
+func (f CaptchaSolverFunc) Solve(ctx context.Context, image []byte) (string, error) {
+	return f(ctx, image)
+}
+
+// RemoteCaptchaSolver 请求西二服务器自动识别验证码，这是 Student 默认使用的识别器
+type RemoteCaptchaSolver struct {
+	URL    string        // 识别接口地址，为空时使用 constants.AutoCaptchaVerifyURL
+	Client *resty.Client // 发起请求的 client，为空时使用一个新的 client
+}
+
+// NewRemoteCaptchaSolver 创建使用默认识别接口的 RemoteCaptchaSolver
+func NewRemoteCaptchaSolver() *RemoteCaptchaSolver {
+	return &RemoteCaptchaSolver{
+		URL:    constants.AutoCaptchaVerifyURL,
+		Client: resty.New(),
+	}
+}
+
+func (r *RemoteCaptchaSolver) Solve(ctx context.Context, image []byte) (string, error) {
+	return r.solveEncoded(ctx, utils.Base64EncodeHTTPImage(image))
+}
+
+// solveEncoded 识别已经编码为 data URL 的验证码图片
+func (r *RemoteCaptchaSolver) solveEncoded(ctx context.Context, image string) (string, error) {
+	url := r.URL
+	if url == "" {
+		url = constants.AutoCaptchaVerifyURL
+	}
+	client := r.Client
+	if client == nil {
+		client = resty.New()
+	}
+
+	code := verifyCodeResponse{}
+	resp, err := client.R().SetContext(ctx).SetFormData(map[string]string{
+		"validateCode": image,
+	}).Post(url)
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", errno.HTTPQueryError.WithMessage("automatic code identification failed")
+	}
+
+	err = json.Unmarshal(resp.Body(), &code)
+	if err != nil {
+		return "", errno.HTTPQueryError.WithErr(err)
+	}
+	return code.Message, nil
+}
+
+// NewManualCaptchaSolver 创建一个把验证码图片交给 callback 处理的识别器
+// 适用于需要用户手动输入验证码，或者在离线测试中固定返回验证码的场景
+func NewManualCaptchaSolver(callback func(image []byte) (string, error)) CaptchaSolver {
+	return CaptchaSolverFunc(func(ctx context.Context, image []byte) (string, error) {
+		if err := contextError(ctx); err != nil {
+			return "", err
+		}
+		return callback(image)
+	})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/west2-online/jwch/parse"
+)
+
+// decodeTransport 将文本响应统一转换为 UTF-8，解析页面时不需要再关心编码
+// 教务处 :82 端口的 asp 页面（校历、当前周、登录验证）使用 GB2312，只在 meta 标签中声明编码
+type decodeTransport struct {
+	next http.RoundTripper
+}
+
+func (t *decodeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !isText(resp.Header.Get("Content-Type")) {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := parse.Decode(body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		// 无法解码时保持原样，由解析时报错
+		decoded = body
+	} else {
+		resp.Header.Set("Content-Type", utf8ContentType(resp.Header.Get("Content-Type")))
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(decoded))
+	resp.ContentLength = int64(len(decoded))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(decoded)))
+	return resp, nil
+}
+
+// isText 是否为需要解码的文本响应，没有 Content-Type 时按页面处理
+func isText(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "javascript") || mediaType == "application/xhtml+xml"
+}
+
+// utf8ContentType 将 Content-Type 中的编码改为 utf-8
+func utf8ContentType(contentType string) string {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" {
+		mediaType, params = "text/html", map[string]string{}
+	}
+	params["charset"] = "utf-8"
+	return mime.FormatMediaType(mediaType, params)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+// alertRegexp 匹配页面中 alert 弹出的提示信息
+var alertRegexp = regexp.MustCompile(`alert\s*\(\s*['"]([^'"]*)['"]\s*\)`)
+
+// 会话失效时教务处返回的页面中的关键字
+var sessionExpiredMarkers = []string{"重新登录", "处理URL失败"}
+
+// 欠缴学费时提示信息中的关键字
+var arrearsMarkers = []string{"学费", "欠费", "缴清"}
+
+// classifyResponse 识别教务处返回的提示页面：会话失效、评议、系统维护、欠费和其他 alert
+// 正常页面返回 nil，提示页面返回带有教务处提示内容的 ErrNo
+func classifyResponse(body string, doc *html.Node) error {
+	for _, marker := range sessionExpiredMarkers {
+		if strings.Contains(body, marker) {
+			return errno.CookieError
+		}
+	}
+	if strings.Contains(body, "请先对任课教师进行测评") {
+		return errno.EvaluationNotFoundError
+	}
+	if doc == nil {
+		return nil
+	}
+	// 被重定向到了登录页
+	if htmlquery.FindOne(doc, `//input[@name="muser"]`) != nil {
+		return errno.CookieError
+	}
+
+	if message := alertMessage(doc); message != "" {
+		switch {
+		case strings.Contains(message, "维护"):
+			return errno.MaintenanceError.WithMessage(message)
+		case containsAny(message, arrearsMarkers):
+			return errno.TuitionArrearsError.WithMessage(message)
+		// 正常页面都在 ASP.NET 的 form 中，只有 alert 没有 form 的页面是提示页面
+		case htmlquery.FindOne(doc, `//form`) == nil:
+			return errno.JwchAlertError.WithMessage(message)
+		}
+	}
+	if title := htmlquery.FindOne(doc, `//title`); title != nil {
+		if text := strings.TrimSpace(htmlquery.InnerText(title)); strings.Contains(text, "维护") {
+			return errno.MaintenanceError.WithMessage(text)
+		}
+	}
+	return nil
+}
+
+// alertMessage 返回页面脚本中第一个 alert 的内容，不包含 onclick 等事件中的 alert
+func alertMessage(doc *html.Node) string {
+	for _, script := range htmlquery.Find(doc, `//script`) {
+		if m := alertRegexp.FindStringSubmatch(htmlquery.InnerText(script)); m != nil {
+			return strings.TrimSpace(m[1])
+		}
+	}
+	return ""
+}
+
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -34,34 +34,40 @@ var (
 	globalConfigOnce sync.Once
 )
 
-// LoadConfigFromEnv 从环境变量加载配置
+// LoadConfigFromEnv 从环境变量加载配置，结果在进程内缓存，未指定 WithConfig 时 NewStudent 使用该配置
 func LoadConfigFromEnv() *Config {
 	globalConfigOnce.Do(func() {
-		globalConfig = &Config{
-			Proxy: ProxyConfig{
-				Enabled: false,
-			},
-		}
-
-		// 从环境变量读取代理配置
-		if authKey := os.Getenv("QINGGUO_AUTH_KEY"); authKey != "" {
-			globalConfig.Proxy.AuthKey = authKey
-		}
-		if authPwd := os.Getenv("QINGGUO_AUTH_PWD"); authPwd != "" {
-			globalConfig.Proxy.AuthPwd = authPwd
-		}
-		if enabled := os.Getenv("QINGGUO_PROXY_ENABLED"); enabled == "true" {
-			globalConfig.Proxy.Enabled = true
-		}
+		globalConfig = NewConfigFromEnv()
 	})
 
 	return globalConfig
 }
 
+// NewConfigFromEnv 从环境变量读取一份新的配置，不会与其他 Student 共享
+func NewConfigFromEnv() *Config {
+	config := &Config{
+		Proxy: ProxyConfig{
+			Enabled: false,
+		},
+	}
+
+	// 从环境变量读取代理配置
+	if authKey := os.Getenv("QINGGUO_AUTH_KEY"); authKey != "" {
+		config.Proxy.AuthKey = authKey
+	}
+	if authPwd := os.Getenv("QINGGUO_AUTH_PWD"); authPwd != "" {
+		config.Proxy.AuthPwd = authPwd
+	}
+	if enabled := os.Getenv("QINGGUO_PROXY_ENABLED"); enabled == "true" {
+		config.Proxy.Enabled = true
+	}
+	return config
+}
+
 // GetTunnelAddress 获取青果网络隧道地址
 func (c *Config) GetTunnelAddress() (string, error) {
 	if !c.Proxy.Enabled || c.Proxy.AuthKey == "" || c.Proxy.AuthPwd == "" {
-		return "", fmt.Errorf("代理未启用或认证信息不完整")
+		return "", errno.ProxyUnavailableError.WithMessage("代理未启用或认证信息不完整")
 	}
 
 	// 1) 优先使用当前Config中已存在的代理地址
@@ -76,8 +82,13 @@ func (c *Config) GetTunnelAddress() (string, error) {
 	params.Set("key", c.Proxy.AuthKey)
 	params.Set("pwd", c.Proxy.AuthPwd)
 
+	tunnelURL := c.Proxy.TunnelURL
+	if tunnelURL == "" {
+		tunnelURL = constants.QingGuoTunnelURL
+	}
+
 	// 发送GET请求
-	resp, err := client.Get(constants.QingGuoTunnelURL + "?" + params.Encode())
+	resp, err := client.Get(tunnelURL + "?" + params.Encode())
 	if err != nil {
 		return "", errno.HTTPQueryError.WithMessage("获取隧道地址失败").WithErr(err)
 	}
@@ -89,18 +100,18 @@ func (c *Config) GetTunnelAddress() (string, error) {
 	}
 
 	if tunnelResp.Code != "SUCCESS" {
-		return "", fmt.Errorf("获取隧道地址失败，响应码: %s", tunnelResp.Code)
+		return "", errno.ProxyUnavailableError.WithMessage("获取隧道地址失败，响应码: " + tunnelResp.Code)
 	}
 
 	// 检查是否有可用的隧道数据
 	if len(tunnelResp.Data) == 0 {
-		return "", fmt.Errorf("没有可用的隧道地址")
+		return "", errno.ProxyUnavailableError.WithMessage("没有可用的隧道地址")
 	}
 
 	// 使用第一个可用的隧道地址
 	tunnelServer := tunnelResp.Data[0].Server
 	if tunnelServer == "" {
-		return "", fmt.Errorf("隧道地址为空")
+		return "", errno.ProxyUnavailableError.WithMessage("隧道地址为空")
 	}
 
 	// 更新配置中的代理服务器地址
@@ -111,14 +122,29 @@ func (c *Config) GetTunnelAddress() (string, error) {
 // GetProxyURL 根据青果网络文档生成代理URL
 func (c *Config) GetProxyURL() (*url.URL, error) {
 	if !c.Proxy.Enabled {
-		return nil, fmt.Errorf("代理未启用")
+		return nil, errno.ProxyUnavailableError.WithMessage("代理未启用")
 	}
 
 	if c.Proxy.AuthKey == "" || c.Proxy.AuthPwd == "" || c.Proxy.ProxyServer == "" {
-		return nil, fmt.Errorf("代理配置信息不完整")
+		return nil, errno.ProxyUnavailableError.WithMessage("代理配置信息不完整")
 	}
 
 	// 普通模式：每次请求都自动切换IP
 	link := fmt.Sprintf("http://%s:%s@%s", c.Proxy.AuthKey, c.Proxy.AuthPwd, c.Proxy.ProxyServer)
 	return url.Parse(link)
 }
+
+// ProxyPool 返回使用该配置的青果网络代理池，同一个 Config 只会创建一个代理池
+// 隧道失效后会被剔除并重新查询，而不是像 GetTunnelAddress 那样一直使用第一次获取的地址
+func (c *Config) ProxyPool() *ProxyPool {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	if c.pool == nil {
+		c.pool = NewProxyPool(&QingGuoProvider{
+			AuthKey: c.Proxy.AuthKey,
+			AuthPwd: c.Proxy.AuthPwd,
+			URL:     c.Proxy.TunnelURL,
+		})
+	}
+	return c.pool
+}
@@ -24,6 +24,8 @@ const (
 	JSQueryURL          = "https://jwcjwxt2.fzu.edu.cn:81/student/glbm/computer/jsj_cszt.aspx"
 	UserInfoURL         = "https://jwcjwxt2.fzu.edu.cn:81/jcxx/xsxx/StudentInformation.aspx"
 	SSOLoginURL         = "https://jwcjwxt2.fzu.edu.cn/Sfrz/SSOLogin"
+	LoginCheckURL       = "https://jwcjwxt2.fzu.edu.cn:82/logincheck.asp"
+	LoginChkURL         = "https://jwcjwxt2.fzu.edu.cn:81/loginchk_xs.aspx"
 	SchoolCalendarURL   = "https://jwcjwxt2.fzu.edu.cn:82/xl.asp"
 	CreditQueryURL      = "https://jwcjwxt2.fzu.edu.cn:81/student/xyzk/xftj/CreditStatistics.aspx"
 	GPAQueryURL         = "https://jwcjwxt2.fzu.edu.cn:81/student/xyzk/jdpm/GPA_sheet.aspx"
@@ -41,6 +43,7 @@ const (
 	JwchPrefix  = "https://jwcjwxt2.fzu.edu.cn:81"
 	JwchReferer = "https://jwcjwxt2.fzu.edu.cn:82/"
 	JwchOrigin  = "https://jwcjwxt2.fzu.edu.cn:82/"
+	SSOPrefix   = "https://jwcjwxt2.fzu.edu.cn"
 
 	AutoCaptchaVerifyURL = "https://statistics.fzuhelper.w2fzu.com/api/login/validateCode?validateCode"
 
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/west2-online/jwch/constants"
+	"github.com/west2-online/jwch/errno"
+)
+
+// Endpoints 教务处各接口的地址，默认值与 constants 中的常量一致
+// 可以整体替换为镜像站、内网反向代理或本地模拟服务器的地址
+type Endpoints struct {
+	JwchPrefix  string `json:"jwch_prefix"`  // 教务系统（81 端口）地址前缀，用于拼接重定向、课程大纲、培养方案等链接
+	JwchReferer string `json:"jwch_referer"` // 请求时携带的 Referer
+	JwchOrigin  string `json:"jwch_origin"`  // 请求时携带的 Origin
+	SSOPrefix   string `json:"sso_prefix"`   // 统一身份认证地址前缀
+
+	// 登录
+	VerifyCodeURL        string `json:"verify_code_url"`         // 验证码图片
+	LoginCheckURL        string `json:"login_check_url"`         // 账号密码验证
+	SSOLoginURL          string `json:"sso_login_url"`           // SSO 登录
+	LoginChkURL          string `json:"login_chk_url"`           // 获取会话 Cookie
+	AutoCaptchaVerifyURL string `json:"auto_captcha_verify_url"` // 西二验证码自动识别接口
+
+	// 需要登录的页面
+	UserInfoURL       string `json:"user_info_url"`
+	CourseURL         string `json:"course_url"`
+	MarksQueryURL     string `json:"marks_query_url"`
+	CETQueryURL       string `json:"cet_query_url"`
+	JSQueryURL        string `json:"js_query_url"`
+	CreditQueryURL    string `json:"credit_query_url"`
+	GPAQueryURL       string `json:"gpa_query_url"`
+	ExamRoomQueryURL  string `json:"exam_room_query_url"`
+	ClassroomQueryURL string `json:"classroom_query_url"`
+	CultivatePlanURL  string `json:"cultivate_plan_url"`
+	LectureURL        string `json:"lecture_url"`
+
+	// 无需登录的页面
+	SchoolCalendarURL  string `json:"school_calendar_url"`
+	LocateDateURL      string `json:"locate_date_url"`
+	NoticeURLPrefix    string `json:"notice_url_prefix"` // 教务处官网地址前缀，以 / 结尾
+	NoticeInfoQueryURL string `json:"notice_info_query_url"`
+}
+
+// DefaultEndpoints 返回教务处线上环境的地址
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		JwchPrefix:  constants.JwchPrefix,
+		JwchReferer: constants.JwchReferer,
+		JwchOrigin:  constants.JwchOrigin,
+		SSOPrefix:   constants.SSOPrefix,
+
+		VerifyCodeURL:        constants.VerifyCodeURL,
+		LoginCheckURL:        constants.LoginCheckURL,
+		SSOLoginURL:          constants.SSOLoginURL,
+		LoginChkURL:          constants.LoginChkURL,
+		AutoCaptchaVerifyURL: constants.AutoCaptchaVerifyURL,
+
+		UserInfoURL:       constants.UserInfoURL,
+		CourseURL:         constants.CourseURL,
+		MarksQueryURL:     constants.MarksQueryURL,
+		CETQueryURL:       constants.CETQueryURL,
+		JSQueryURL:        constants.JSQueryURL,
+		CreditQueryURL:    constants.CreditQueryURL,
+		GPAQueryURL:       constants.GPAQueryURL,
+		ExamRoomQueryURL:  constants.ExamRoomQueryURL,
+		ClassroomQueryURL: constants.ClassroomQueryURL,
+		CultivatePlanURL:  constants.CultivatePlanURL,
+		LectureURL:        constants.LectureURL,
+
+		SchoolCalendarURL:  constants.SchoolCalendarURL,
+		LocateDateURL:      constants.JwchLocateDateUrl,
+		NoticeURLPrefix:    constants.JwchNoticeURLPrefix,
+		NoticeInfoQueryURL: constants.NoticeInfoQueryURL,
+	}
+}
+
+// WithBaseURL 将所有地址的 scheme 和 host 替换为 baseURL，路径拼接在 baseURL 的路径之后
+// 教务处各个主机上的路径互不冲突，因此可以用一个地址对接本地模拟服务器或反向代理
+func (e Endpoints) WithBaseURL(baseURL string) (Endpoints, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return e, errno.ParamError.WithMessage("invalid base url: " + baseURL)
+	}
+
+	basePath := strings.TrimSuffix(base.Path, "/")
+	for _, field := range e.fields() {
+		u, err := url.Parse(*field)
+		if err != nil {
+			return e, errno.ParamError.WithMessage("invalid endpoint: " + *field)
+		}
+		u.Scheme = base.Scheme
+		u.Host = base.Host
+		u.User = base.User
+		u.Path = basePath + u.Path
+		u.RawPath = ""
+		*field = u.String()
+	}
+	return e, nil
+}
+
+// ReplacePrefix 将所有以 oldPrefix 开头的地址替换为以 newPrefix 开头
+// 用于教务处单独更换某个主机或端口的情况，例如把 https://jwcjwxt2.fzu.edu.cn:82 换成新的地址
+func (e Endpoints) ReplacePrefix(oldPrefix, newPrefix string) Endpoints {
+	for _, field := range e.fields() {
+		if strings.HasPrefix(*field, oldPrefix) {
+			*field = newPrefix + strings.TrimPrefix(*field, oldPrefix)
+		}
+	}
+	return e
+}
+
+// fields 返回所有地址字段的指针，便于统一改写
+func (e *Endpoints) fields() []*string {
+	return []*string{
+		&e.JwchPrefix, &e.JwchReferer, &e.JwchOrigin, &e.SSOPrefix,
+		&e.VerifyCodeURL, &e.LoginCheckURL, &e.SSOLoginURL, &e.LoginChkURL, &e.AutoCaptchaVerifyURL,
+		&e.UserInfoURL, &e.CourseURL, &e.MarksQueryURL, &e.CETQueryURL, &e.JSQueryURL, &e.CreditQueryURL,
+		&e.GPAQueryURL, &e.ExamRoomQueryURL, &e.ClassroomQueryURL, &e.CultivatePlanURL, &e.LectureURL,
+		&e.SchoolCalendarURL, &e.LocateDateURL, &e.NoticeURLPrefix, &e.NoticeInfoQueryURL,
+	}
+}
+
+// noticeOrigin 教务处官网地址（不带结尾的 /），登录验证时作为 Referer 和 Origin
+func (e Endpoints) noticeOrigin() string {
+	return strings.TrimSuffix(e.NoticeURLPrefix, "/")
+}
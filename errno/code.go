@@ -22,12 +22,23 @@ const (
 	SuccessMsg  = "success"
 
 	// Error
-	ServiceErrorCode           = 10001 // 默认服务错误
-	ParamErrorCode             = 10002 // 参数错误
-	HTTPQueryErrorCode         = 10003 // HTTP请求出错
-	AuthorizationFailedErrCode = 10004 // 鉴权失败
-	UnexpectedTypeErrorCode    = 10005 // 未知类型
-	NotImplementErrorCode      = 10006 // 未实装
-	NeedEvaluationErrorCode    = 10007 // 需要测评
-	JwchNetworkErrorCode       = 10008 // 教务处网络异常
+	ServiceErrorCode            = 10001 // 默认服务错误
+	ParamErrorCode              = 10002 // 参数错误
+	HTTPQueryErrorCode          = 10003 // HTTP请求出错
+	AuthorizationFailedErrCode  = 10004 // 鉴权失败
+	UnexpectedTypeErrorCode     = 10005 // 未知类型
+	NotImplementErrorCode       = 10006 // 未实装
+	NeedEvaluationErrorCode     = 10007 // 需要测评
+	JwchNetworkErrorCode        = 10008 // 教务处网络异常
+	ContextCanceledErrorCode    = 10009 // 请求被取消或超时
+	SessionNotFoundErrorCode    = 10010 // 会话不存在
+	ProxyUnavailableErrorCode   = 10011 // 没有可用的代理
+	WrongPasswordErrorCode      = 10012 // 用户名或密码错误
+	WrongCaptchaErrorCode       = 10013 // 验证码错误
+	AccountNotFoundErrorCode    = 10014 // 账号不存在
+	AccountLockedErrorCode      = 10015 // 账号被锁定
+	MaintenanceErrorCode        = 10016 // 教务处系统维护
+	UnexpectedRedirectErrorCode = 10017 // 登录时出现无法识别的重定向
+	TuitionArrearsErrorCode     = 10018 // 欠缴学费，无法查询
+	JwchAlertErrorCode          = 10019 // 教务处弹出提示，没有返回正常页面
 )
@@ -30,10 +30,24 @@ var (
 	CookieError             = NewErrNo(AuthorizationFailedErrCode, "id error or session expired")
 	LoginCheckFailedError   = NewErrNo(AuthorizationFailedErrCode, "login check failed")
 	SSOLoginFailedError     = NewErrNo(AuthorizationFailedErrCode, "sso login failed")
+	WrongPasswordError      = NewErrNo(WrongPasswordErrorCode, "wrong username or password")
+	WrongCaptchaError       = NewErrNo(WrongCaptchaErrorCode, "captcha rejected")
+	AccountNotFoundError    = NewErrNo(AccountNotFoundErrorCode, "account not found")
+	AccountLockedError      = NewErrNo(AccountLockedErrorCode, "account locked")
+	MaintenanceError        = NewErrNo(MaintenanceErrorCode, "jwch is under maintenance")
+	UnexpectedRedirectError = NewErrNo(UnexpectedRedirectErrorCode, "unexpected redirect during login")
+	TuitionArrearsError     = NewErrNo(TuitionArrearsErrorCode, "tuition arrears")
+	JwchAlertError          = NewErrNo(JwchAlertErrorCode, "jwch alert")
 	EvaluationNotFoundError = NewErrNo(NeedEvaluationErrorCode, "evaluation not found")
+	SessionNotFoundError    = NewErrNo(SessionNotFoundErrorCode, "session not found")
 	JwchNetworkError        = NewErrNo(JwchNetworkErrorCode, "jwch network error")
+	ProxyUnavailableError   = NewErrNo(ProxyUnavailableErrorCode, "no proxy available")
 
 	// HTTP
-	HTTPQueryError = NewErrNo(HTTPQueryErrorCode, "HTTP query failed")
-	HTMLParseError = NewErrNo(HTTPQueryErrorCode, "HTML parse failed")
+	HTTPQueryError     = NewErrNo(HTTPQueryErrorCode, "HTTP query failed")
+	HTMLParseError     = NewErrNo(HTTPQueryErrorCode, "HTML parse failed")
+	ExamTimeParseError = NewErrNo(HTTPQueryErrorCode, "exam time parse failed")
+
+	// Context
+	ContextCanceledError = NewErrNo(ContextCanceledErrorCode, "request canceled or deadline exceeded")
 )
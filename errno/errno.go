@@ -17,23 +17,45 @@ limitations under the License.
 package errno
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
 )
 
 type ErrNo struct {
 	ErrorCode int64
 	ErrorMsg  string
+	Attempts  int    // 请求的尝试次数，只有经过重试的网络错误才会设置
+	Op        string // 出错的操作，例如 GetMarks
+	URL       string // 出错的请求地址，不包含 id 等参数
+
+	cause    error  // 底层错误，通过 errors.Unwrap 获取
+	sentinel uint32 // 创建该错误的 NewErrNo 的编号，用于 errors.Is
 }
 
 func (e ErrNo) Error() string {
-	return fmt.Sprintf("error code: %d, error msg: %s", e.ErrorCode, e.ErrorMsg)
+	var b strings.Builder
+	fmt.Fprintf(&b, "error code: %d, error msg: %s", e.ErrorCode, e.ErrorMsg)
+	if e.Attempts > 1 {
+		fmt.Fprintf(&b, ", attempts: %d", e.Attempts)
+	}
+	if e.cause != nil {
+		fmt.Fprintf(&b, ", cause: %v", e.cause)
+	}
+	return b.String()
 }
 
+// sentinels NewErrNo 已经分配的编号
+var sentinels atomic.Uint32
+
 func NewErrNo(code int64, msg string) ErrNo {
 	return ErrNo{
 		ErrorCode: code,
 		ErrorMsg:  msg,
+		sentinel:  sentinels.Add(1),
 	}
 }
 
@@ -42,20 +64,82 @@ func (e ErrNo) WithMessage(msg string) ErrNo {
 	return e
 }
 
+// WithErr 记录底层错误，可以通过 errors.Unwrap、errors.As 获取
 func (e ErrNo) WithErr(err error) ErrNo {
-	e.ErrorMsg = err.Error()
+	e.cause = err
+	return e
+}
+
+// WithAttempts 记录请求的尝试次数
+func (e ErrNo) WithAttempts(attempts int) ErrNo {
+	e.Attempts = attempts
+	return e
+}
+
+// WithOp 记录出错的操作
+func (e ErrNo) WithOp(op string) ErrNo {
+	e.Op = op
+	return e
+}
+
+// WithURL 记录出错的请求地址
+func (e ErrNo) WithURL(url string) ErrNo {
+	e.URL = url
 	return e
 }
 
+// Unwrap 返回底层错误
+func (e ErrNo) Unwrap() error {
+	return e.cause
+}
+
+// Is 判断是否由 target 派生，与 ErrorMsg 等字段无关，例如 errors.Is(err, errno.CookieError)
+// target 不是 default.go 中的预定义错误时比较错误码
+func (e ErrNo) Is(target error) bool {
+	t, ok := target.(ErrNo)
+	if !ok {
+		return false
+	}
+	if t.sentinel != 0 && e.sentinel != 0 {
+		return t.sentinel == e.sentinel
+	}
+	return t.ErrorCode == e.ErrorCode
+}
+
+// retryableCodes 原样重试可能成功的错误码
+var retryableCodes = map[int64]bool{
+	JwchNetworkErrorCode:      true,
+	ProxyUnavailableErrorCode: true,
+	MaintenanceErrorCode:      true,
+	WrongCaptchaErrorCode:     true,
+}
+
+// Retryable 报告稍后原样重试是否可能成功，例如网络异常、系统维护和验证码识别错误
+// 会话过期、密码错误、解析失败等需要调用方处理的错误返回 false
+func (e ErrNo) Retryable() bool {
+	return retryableCodes[e.ErrorCode]
+}
+
 // ConvertErr convert error to ErrNo
-// in Default user ServiceErrorCode
+// 取消或超时转换为 ContextCanceledError，网络错误转换为 JwchNetworkError，其他错误转换为 ServiceError
+// 原错误都可以通过 errors.Unwrap 获取
 func ConvertErr(err error) ErrNo {
 	errno := ErrNo{}
 	if errors.As(err, &errno) {
 		return errno
 	}
 
-	s := ServiceError
+	var s ErrNo
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		s = ContextCanceledError
+	case errors.As(err, &netErr):
+		s = JwchNetworkError
+	default:
+		s = ServiceError
+	}
 	s.ErrorMsg = err.Error()
+	s.cause = err
 	return s
 }
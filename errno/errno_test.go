@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errno_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+func TestErrNoIs(t *testing.T) {
+	if !errors.Is(errno.CookieError.WithMessage("session expired").WithOp("GetMarks"), errno.CookieError) {
+		t.Errorf("errors.Is should ignore message and op")
+	}
+	// 错误码相同的不同预定义错误不能互相匹配
+	if errors.Is(errno.CookieError, errno.AccountConflictError) {
+		t.Errorf("CookieError should not match AccountConflictError")
+	}
+	if !errors.Is(errno.AccountConflictError, errno.ErrNo{ErrorCode: errno.AuthorizationFailedErrCode}) {
+		t.Errorf("target without sentinel should match by code")
+	}
+
+	err := errno.HTMLParseError.WithErr(io.ErrUnexpectedEOF)
+	if !errors.Is(err, io.ErrUnexpectedEOF) || !errors.Is(err, errno.HTMLParseError) || errors.Unwrap(err) != io.ErrUnexpectedEOF {
+		t.Errorf("cause not wrapped: %v", err)
+	}
+	if err.ErrorMsg != errno.HTMLParseError.ErrorMsg || !strings.Contains(err.Error(), io.ErrUnexpectedEOF.Error()) {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestErrNoRetryable(t *testing.T) {
+	for _, e := range []errno.ErrNo{errno.JwchNetworkError, errno.MaintenanceError, errno.WrongCaptchaError, errno.ProxyUnavailableError} {
+		if !e.Retryable() {
+			t.Errorf("%v should be retryable", e)
+		}
+	}
+	for _, e := range []errno.ErrNo{errno.CookieError, errno.WrongPasswordError, errno.HTMLParseError, errno.ContextCanceledError} {
+		if e.Retryable() {
+			t.Errorf("%v should not be retryable", e)
+		}
+	}
+}
+
+func TestConvertErr(t *testing.T) {
+	cause := errors.New("boom")
+	e := errno.ConvertErr(cause)
+	if e.ErrorCode != errno.ServiceErrorCode || !errors.Is(e, cause) {
+		t.Errorf("unexpected conversion %v", e)
+	}
+	if e = errno.ConvertErr(context.Canceled); !errors.Is(e, errno.ContextCanceledError) || !errors.Is(e, context.Canceled) {
+		t.Errorf("unexpected conversion %v", e)
+	}
+}
@@ -0,0 +1,25 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import "github.com/west2-online/jwch/parse"
+
+// ParseExamSchedule 解析教务处的考试时间地点文本
+// raw 为空（尚未安排考试）时返回 nil, nil；格式无法识别时返回 ExamTimeParseError
+func ParseExamSchedule(raw string) (*ExamSchedule, error) {
+	return parse.ExamSchedule(raw)
+}
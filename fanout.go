@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 并发查询默认的最大 worker 数
+const defaultFanOutConcurrency = 4
+
+// FanOutStats 一次并发查询的统计数据
+type FanOutStats struct {
+	Op            string        // 查询名称，例如 GetEmptyRoom
+	Tasks         int           // 子任务数
+	Workers       int           // 实际使用的 worker 数
+	QueueDelay    time.Duration // 子任务从提交到开始执行的平均等待时长
+	MaxQueueDelay time.Duration // 子任务从提交到开始执行的最长等待时长
+	Elapsed       time.Duration // 整个查询的耗时
+	Err           error         // 查询的错误，成功时为 nil
+}
+
+// FanOutObserver 接收并发查询的统计数据，会在查询结束时同步调用
+type FanOutObserver func(FanOutStats)
+
+// fanOut 使用最多 s.fanOutConcurrency 个 worker 执行 n 个子任务，结果按子任务的顺序合并
+// 任意一个子任务失败后取消其余子任务，ctx 被取消时立即返回
+func (s *Student) fanOut(ctx context.Context, op string, n int, task func(ctx context.Context, i int) ([]string, error)) ([]string, error) {
+	start := time.Now()
+	workers := s.fanOutConcurrency
+	if workers <= 0 || workers > n {
+		workers = n
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		results  = make([][]string, n)
+		errOnce  sync.Once
+		firstErr error
+		delayMu  sync.Mutex
+		started  int
+		total    time.Duration
+		maxDelay time.Duration
+		wg       sync.WaitGroup
+	)
+	tasks := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				delay := time.Since(start)
+				delayMu.Lock()
+				started++
+				total += delay
+				maxDelay = max(maxDelay, delay)
+				delayMu.Unlock()
+
+				rooms, err := task(ctx, i)
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				results[i] = rooms
+			}
+		}()
+	}
+
+	// 所有子任务在开始时一起提交，排队时长即从开始到被 worker 取走的时长
+submit:
+	for i := 0; i < n; i++ {
+		select {
+		case tasks <- i:
+		case <-ctx.Done():
+			break submit
+		}
+	}
+	close(tasks)
+	wg.Wait()
+
+	err := firstErr
+	if err == nil {
+		err = contextError(ctx)
+	}
+
+	stats := FanOutStats{Op: op, Tasks: n, Workers: workers, MaxQueueDelay: maxDelay, Elapsed: time.Since(start), Err: err}
+	if started > 0 {
+		stats.QueueDelay = total / time.Duration(started)
+	}
+	s.logger.DebugContext(ctx, "jwch: fan-out finished", "op", op, "tasks", n, "workers", workers,
+		"queue_delay", stats.QueueDelay, "max_queue_delay", stats.MaxQueueDelay, "elapsed", stats.Elapsed)
+	if s.fanOutObserver != nil {
+		s.fanOutObserver(stats)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	var merged []string
+	for _, rooms := range results {
+		merged = append(merged, rooms...)
+	}
+	return merged, nil
+}
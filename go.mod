@@ -4,12 +4,10 @@ go 1.23.4
 
 require (
 	github.com/antchfx/htmlquery v1.3.3
+	github.com/antchfx/xpath v1.3.2
 	github.com/go-resty/resty/v2 v2.15.3
 	golang.org/x/net v0.38.0
 	golang.org/x/text v0.23.0
 )
 
-require (
-	github.com/antchfx/xpath v1.3.2 // indirect
-	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
-)
+require github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RequestInfo 传给 hook 的请求信息
+// 不包含请求头、Cookie 和表单，URL 中的 id、token 等参数已经脱敏，可以直接输出到日志
+type RequestInfo struct {
+	Op        string    // 逻辑操作名，例如 GetMarks，见 OperationFromContext
+	Method    string    // HTTP 方法
+	URL       string    // 脱敏后的 URL
+	Start     time.Time // 请求开始的时间
+	BytesSent int64     // 请求体的字节数，未知时为 -1
+}
+
+// ResponseInfo 传给 hook 的响应信息
+type ResponseInfo struct {
+	RequestInfo
+	StatusCode    int           // HTTP 状态码，请求失败时为 0
+	Latency       time.Duration // 从请求开始到读完响应体（或请求失败）的耗时
+	BytesReceived int64         // 响应体的字节数
+	Err           error         // 请求失败的原因，只在 OnError 中不为 nil
+}
+
+// Hook 请求的回调，字段都可以为 nil
+// 每次实际发出的请求（包括重试和重定向后的请求）都会触发一次，回调需要是并发安全的
+type Hook struct {
+	// BeforeRequest 在请求发出前调用，返回的 ctx 会用于该请求和之后的回调（为 nil 时沿用原 ctx），可以用来传递 span
+	BeforeRequest func(ctx context.Context, req *RequestInfo) context.Context
+	// AfterResponse 在收到响应并读完响应体后调用，3xx、4xx、5xx 也会触发
+	AfterResponse func(ctx context.Context, resp *ResponseInfo)
+	// OnError 在请求没有收到响应（网络错误、超时、被取消等）时调用
+	OnError func(ctx context.Context, resp *ResponseInfo)
+}
+
+type operationKey struct{}
+
+// ContextWithOperation 返回带有逻辑操作名的 ctx，hook 通过 RequestInfo.Op 获取
+// Student 的公开方法会自动设置操作名，通过 NewRequest 自行发起请求时可以使用该函数
+func ContextWithOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFromContext 返回 ctx 中的逻辑操作名，没有时返回空字符串
+func OperationFromContext(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
+
+// defaultOperation ctx 中还没有操作名时设置为 op
+func defaultOperation(ctx context.Context, op string) context.Context {
+	if OperationFromContext(ctx) != "" {
+		return ctx
+	}
+	return ContextWithOperation(ctx, op)
+}
+
+// 这些查询参数的值包含学号、会话标识或凭证，传给 hook 前替换为 Redacted
+var sensitiveQueryParams = []string{"id", "num", "token", "key", "pwd", "passwd", "password", "muser"}
+
+// redactURL 去掉 URL 中的用户信息和敏感的查询参数
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	redacted.User = nil
+	if redacted.RawQuery != "" {
+		query := redacted.Query()
+		for key := range query {
+			if slices.Contains(sensitiveQueryParams, key) {
+				query.Set(key, Redacted)
+			}
+		}
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
+// hookChain Student 上的 hook 列表，可以在创建后继续添加
+type hookChain struct {
+	mu    sync.RWMutex
+	hooks []Hook
+}
+
+func (c *hookChain) add(hooks ...Hook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, hooks...)
+}
+
+func (c *hookChain) snapshot() []Hook {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hooks
+}
+
+// hookTransport 在请求前后调用 hook
+type hookTransport struct {
+	chain *hookChain
+	next  http.RoundTripper
+}
+
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	hooks := t.chain.snapshot()
+	if len(hooks) == 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	info := RequestInfo{
+		Op:        OperationFromContext(ctx),
+		Method:    req.Method,
+		URL:       redactURL(req.URL),
+		Start:     time.Now(),
+		BytesSent: req.ContentLength,
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		info.BytesSent = 0
+	}
+	for _, h := range hooks {
+		if h.BeforeRequest == nil {
+			continue
+		}
+		if next := h.BeforeRequest(ctx, &info); next != nil {
+			ctx = next
+		}
+	}
+	if ctx != req.Context() {
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		result := &ResponseInfo{RequestInfo: info, Latency: time.Since(info.Start), Err: err}
+		// 与 BeforeRequest 相反的顺序调用，方便 hook 嵌套
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if hooks[i].OnError != nil {
+				hooks[i].OnError(ctx, result)
+			}
+		}
+		return nil, err
+	}
+
+	resp.Body = &hookBody{ReadCloser: resp.Body, done: func(n int64) {
+		result := &ResponseInfo{RequestInfo: info, StatusCode: resp.StatusCode, Latency: time.Since(info.Start), BytesReceived: n}
+		for i := len(hooks) - 1; i >= 0; i-- {
+			if hooks[i].AfterResponse != nil {
+				hooks[i].AfterResponse(ctx, result)
+			}
+		}
+	}}
+	return resp, nil
+}
+
+// hookBody 统计响应体的字节数，关闭时调用 done
+type hookBody struct {
+	io.ReadCloser
+	n    int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *hookBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *hookBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.n) })
+	return err
+}
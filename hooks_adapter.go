@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// SlogHook 使用 slog 记录每个请求，成功的请求使用 level，失败的请求和 5xx 使用 Warn
+// 只输出 RequestInfo 中已经脱敏的字段，不会输出密码、Cookie 和 Identifier
+func SlogHook(logger *slog.Logger, level slog.Level) Hook {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return Hook{
+		AfterResponse: func(ctx context.Context, resp *ResponseInfo) {
+			lvl := level
+			if resp.StatusCode >= http.StatusInternalServerError {
+				lvl = max(level, slog.LevelWarn)
+			}
+			logger.LogAttrs(ctx, lvl, "jwch: request finished",
+				slog.String("op", resp.Op),
+				slog.String("method", resp.Method),
+				slog.String("url", resp.URL),
+				slog.Int("status", resp.StatusCode),
+				slog.Duration("latency", resp.Latency),
+				slog.Int64("bytes_sent", resp.BytesSent),
+				slog.Int64("bytes_received", resp.BytesReceived),
+			)
+		},
+		OnError: func(ctx context.Context, resp *ResponseInfo) {
+			logger.LogAttrs(ctx, max(level, slog.LevelWarn), "jwch: request failed",
+				slog.String("op", resp.Op),
+				slog.String("method", resp.Method),
+				slog.String("url", resp.URL),
+				slog.Duration("latency", resp.Latency),
+				slog.String("error", resp.Err.Error()),
+			)
+		},
+	}
+}
+
+// Span OpenTelemetry 风格的 span，trace.Span 包装一层即可实现
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// Tracer OpenTelemetry 风格的 tracer，trace.Tracer 包装一层即可实现
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// spanKey 每个 TracingHook 使用各自的 key，多个 TracingHook 可以同时使用
+type spanKey struct{ _ int }
+
+// TracingHook 为每个请求创建一个 span，属性名遵循 OpenTelemetry 的 HTTP 语义约定
+// span 名称为操作名（没有时为 HTTP 方法），span 通过 ctx 传给之后的 Transport
+func TracingHook(tracer Tracer) Hook {
+	key := &spanKey{}
+	return Hook{
+		BeforeRequest: func(ctx context.Context, req *RequestInfo) context.Context {
+			name := req.Op
+			if name == "" {
+				name = "HTTP " + req.Method
+			}
+			ctx, span := tracer.Start(ctx, name)
+			span.SetAttribute("jwch.operation", req.Op)
+			span.SetAttribute("http.request.method", req.Method)
+			span.SetAttribute("url.full", req.URL)
+			if req.BytesSent > 0 {
+				span.SetAttribute("http.request.body.size", req.BytesSent)
+			}
+			return context.WithValue(ctx, key, span)
+		},
+		AfterResponse: func(ctx context.Context, resp *ResponseInfo) {
+			span, ok := ctx.Value(key).(Span)
+			if !ok {
+				return
+			}
+			span.SetAttribute("http.response.status_code", resp.StatusCode)
+			span.SetAttribute("http.response.body.size", resp.BytesReceived)
+			span.End()
+		},
+		OnError: func(ctx context.Context, resp *ResponseInfo) {
+			span, ok := ctx.Value(key).(Span)
+			if !ok {
+				return
+			}
+			span.RecordError(resp.Err)
+			span.End()
+		},
+	}
+}
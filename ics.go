@@ -0,0 +1,311 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+const (
+	icsProdID   = "-//west2-online//jwch//CN"
+	icsTZID     = "Asia/Shanghai"
+	icsUIDHost  = "jwch.west2-online"
+	icsLineSize = 75 // RFC 5545 规定每行最多 75 个字节
+)
+
+// ICSOptions 导出课表时的配置
+type ICSOptions struct {
+	TermStart    time.Time // 学期第一周的任意一天，可以通过 SchoolCalendar.TermStartDate 获取
+	CalendarName string    // 日历名称，为空时不输出 X-WR-CALNAME
+	DTStamp      time.Time // 事件的 DTSTAMP，为零值时使用当前时间，固定该值可以得到完全一致的输出
+
+	// ClassPeriods 按校区覆盖内置的作息时间表，键为校区名称，例如 CampusQiShan
+	ClassPeriods map[string]ClassPeriods
+}
+
+// ExportICS 将课表导出为 iCalendar（RFC 5545）格式
+// 单双周课程使用 INTERVAL=2 的 RRULE，被调走或停课的周次写入 EXDATE，调课后的课程单独生成事件，整周课程生成全天事件
+// UID 由课程名称、教师和上课周次节次计算得到，不包含教室，调换教室后重复导入时日历客户端会更新而不是新增事件
+func ExportICS(courses []*Course, opts ICSOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, courses, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteICS 同 ExportICS，结果写入 w
+func WriteICS(w io.Writer, courses []*Course, opts ICSOptions) error {
+	if opts.TermStart.IsZero() {
+		return errno.ParamError.WithMessage("term start is required")
+	}
+	stamp := opts.DTStamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	c := &icsCalendar{
+		termStart: weekStart(opts.TermStart),
+		stamp:     stamp.UTC().Format("20060102T150405Z"),
+		periods:   opts.ClassPeriods,
+	}
+	c.line("BEGIN:VCALENDAR")
+	c.line("VERSION:2.0")
+	c.line("PRODID:" + icsProdID)
+	c.line("CALSCALE:GREGORIAN")
+	c.line("METHOD:PUBLISH")
+	if opts.CalendarName != "" {
+		c.line("X-WR-CALNAME:" + escapeICSText(opts.CalendarName))
+	}
+	c.line("X-WR-TIMEZONE:" + icsTZID)
+	c.line("BEGIN:VTIMEZONE")
+	c.line("TZID:" + icsTZID)
+	c.line("BEGIN:STANDARD")
+	c.line("DTSTART:19700101T000000")
+	c.line("TZOFFSETFROM:+0800")
+	c.line("TZOFFSETTO:+0800")
+	c.line("TZNAME:CST")
+	c.line("END:STANDARD")
+	c.line("END:VTIMEZONE")
+
+	for _, course := range courses {
+		if course == nil {
+			continue
+		}
+		c.writeCourse(course)
+	}
+
+	c.line("END:VCALENDAR")
+	_, err := w.Write(c.buf.Bytes())
+	return err
+}
+
+type icsCalendar struct {
+	buf       bytes.Buffer
+	termStart time.Time
+	stamp     string
+	periods   map[string]ClassPeriods
+}
+
+func (c *icsCalendar) writeCourse(course *Course) {
+	for _, rule := range course.ScheduleRules {
+		// 整周课程展开得到的规则由全天事件表示
+		if rule.FromFullWeek || rule.Adjust {
+			continue
+		}
+		c.writeRule(course, rule)
+	}
+
+	// 调课后的新课程单独生成事件
+	for _, adj := range course.AdjustRules {
+		if adj.Canceled || adj.NewWeek <= 0 || adj.NewWeekday <= 0 {
+			continue
+		}
+		rule := CourseScheduleRule{
+			Location:   adj.NewLocation,
+			StartClass: adj.NewStartClass,
+			EndClass:   adj.NewEndClass,
+			StartWeek:  adj.NewWeek,
+			EndWeek:    adj.NewWeek,
+			Weekday:    adj.NewWeekday,
+			Single:     true,
+			Double:     true,
+			Adjust:     true,
+		}
+		start, end, ok := c.classTime(rule, adj.NewWeek)
+		if !ok {
+			continue
+		}
+		c.beginEvent(course, fmt.Sprintf("adjust|%d|%d|%d-%d|%d|%d|%d-%d",
+			adj.OldWeek, adj.OldWeekday, adj.OldStartClass, adj.OldEndClass,
+			adj.NewWeek, adj.NewWeekday, adj.NewStartClass, adj.NewEndClass))
+		c.line("DTSTART;TZID=" + icsTZID + ":" + formatICSTime(start))
+		c.line("DTEND;TZID=" + icsTZID + ":" + formatICSTime(end))
+		c.eventBody(course, rule, "（调课）")
+		c.line("END:VEVENT")
+	}
+
+	for _, rule := range course.FullWeekScheduleRules {
+		start := c.date(rule.StartWeek, rule.StartWeekDay)
+		end := c.date(rule.EndWeek, rule.EndWeekDay)
+		if end.Before(start) {
+			continue
+		}
+		c.beginEvent(course, fmt.Sprintf("fullweek|%d|%d|%d|%d", rule.StartWeek, rule.StartWeekDay, rule.EndWeek, rule.EndWeekDay))
+		c.line("DTSTART;VALUE=DATE:" + start.Format("20060102"))
+		// 全天事件的 DTEND 不包含在内
+		c.line("DTEND;VALUE=DATE:" + end.AddDate(0, 0, 1).Format("20060102"))
+		c.line("SUMMARY:" + escapeICSText(course.Name))
+		c.line("DESCRIPTION:" + escapeICSText(fmt.Sprintf("任课教师：%s\n第%d周星期%d至第%d周星期%d",
+			course.Teacher, rule.StartWeek, rule.StartWeekDay, rule.EndWeek, rule.EndWeekDay)))
+		c.line("TRANSP:TRANSPARENT")
+		c.line("END:VEVENT")
+	}
+}
+
+// writeRule 为一条常规上课规则生成带 RRULE 的事件
+// 学期跨越冬夏作息切换时上课时间会变化，按上课时间拆分为多个事件
+func (c *icsCalendar) writeRule(course *Course, rule CourseScheduleRule) {
+	interval := 1
+	if !rule.Single || !rule.Double {
+		interval = 2
+	}
+	key := fmt.Sprintf("rule|%d|%d-%d|%d-%d|%t|%t",
+		rule.Weekday, rule.StartClass, rule.EndClass, rule.StartWeek, rule.EndWeek, rule.Single, rule.Double)
+
+	for i, series := range c.ruleSeries(rule) {
+		// 第一段沿用原来的 UID，避免作息不变时重复导入产生新事件
+		uidKey := key
+		if i > 0 {
+			uidKey = fmt.Sprintf("%s|%d", key, i)
+		}
+		c.writeSeries(course, rule, series, interval, uidKey)
+	}
+}
+
+// ruleSeries 将规则的上课周次按上课时间分段，同一段内每周的上下课时间相同
+func (c *icsCalendar) ruleSeries(rule CourseScheduleRule) [][]int {
+	var (
+		series     [][]int
+		lastPeriod ClassPeriod
+	)
+	for _, week := range ScheduleRuleWeeks(rule) {
+		start, end, ok := c.classTime(rule, week)
+		if !ok {
+			continue
+		}
+		day := c.date(week, rule.Weekday)
+		p := ClassPeriod{Start: start.Sub(day), End: end.Sub(day)}
+		if len(series) == 0 || p != lastPeriod {
+			series = append(series, nil)
+			lastPeriod = p
+		}
+		series[len(series)-1] = append(series[len(series)-1], week)
+	}
+	return series
+}
+
+func (c *icsCalendar) writeSeries(course *Course, rule CourseScheduleRule, weeks []int, interval int, key string) {
+	start, end, _ := c.classTime(rule, weeks[0])
+	first, last := weeks[0], weeks[len(weeks)-1]
+
+	c.beginEvent(course, key)
+	c.line("DTSTART;TZID=" + icsTZID + ":" + formatICSTime(start))
+	c.line("DTEND;TZID=" + icsTZID + ":" + formatICSTime(end))
+	if last > first {
+		c.line(fmt.Sprintf("RRULE:FREQ=WEEKLY;INTERVAL=%d;COUNT=%d", interval, (last-first)/interval+1))
+	}
+
+	// 无法确定上课时间的周次不在 weeks 中，但仍在 RRULE 的范围内，需要排除
+	var exdates []string
+	offset := start.Sub(c.date(first, rule.Weekday))
+	for week := first; week <= last; week += interval {
+		if !slices.Contains(weeks, week) {
+			exdates = append(exdates, formatICSTime(c.date(week, rule.Weekday).Add(offset)))
+		}
+	}
+
+	// 被调走或停课的周次
+	for _, adj := range course.AdjustRules {
+		if adj.OldWeekday != rule.Weekday || adj.OldStartClass != rule.StartClass || adj.OldEndClass != rule.EndClass {
+			continue
+		}
+		if !slices.Contains(weeks, adj.OldWeek) {
+			continue
+		}
+		exStart, _, _ := c.classTime(rule, adj.OldWeek)
+		exdates = append(exdates, formatICSTime(exStart))
+	}
+	if len(exdates) > 0 {
+		slices.Sort(exdates)
+		c.line("EXDATE;TZID=" + icsTZID + ":" + strings.Join(exdates, ","))
+	}
+
+	c.eventBody(course, rule, "")
+	c.line("END:VEVENT")
+}
+
+func (c *icsCalendar) beginEvent(course *Course, key string) {
+	c.line("BEGIN:VEVENT")
+	c.line("UID:" + icsUID(c.termStart, course, key))
+	c.line("DTSTAMP:" + c.stamp)
+}
+
+func (c *icsCalendar) eventBody(course *Course, rule CourseScheduleRule, suffix string) {
+	c.line("SUMMARY:" + escapeICSText(course.Name+suffix))
+	if rule.Location != "" {
+		c.line("LOCATION:" + escapeICSText(rule.Location))
+	}
+	c.line("DESCRIPTION:" + escapeICSText(fmt.Sprintf("任课教师：%s\n第%d-%d节", course.Teacher, rule.StartClass, rule.EndClass)))
+}
+
+// date 返回第 week 周星期 weekday 的日期
+func (c *icsCalendar) date(week, weekday int) time.Time {
+	return WeekDate(c.termStart, week, weekday)
+}
+
+// classTime 返回第 week 周这条规则的上下课时间
+func (c *icsCalendar) classTime(rule CourseScheduleRule, week int) (start, end time.Time, ok bool) {
+	interval, ok := classPeriodsOf(c.periods, rule.Location).Interval(c.date(week, rule.Weekday), rule.StartClass, rule.EndClass)
+	return interval.Start, interval.End, ok
+}
+
+func (c *icsCalendar) line(content string) {
+	writeICSLine(&c.buf, content)
+}
+
+func icsUID(termStart time.Time, course *Course, key string) string {
+	sum := sha1.Sum([]byte(strings.Join([]string{termStart.Format("20060102"), course.Name, course.Teacher, key}, "|")))
+	return hex.EncodeToString(sum[:]) + "@" + icsUIDHost
+}
+
+func formatICSTime(t time.Time) string {
+	return t.In(shanghai).Format("20060102T150405")
+}
+
+// escapeICSText 按 RFC 5545 转义 TEXT 类型的值
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// writeICSLine 写入一行内容，超过 75 字节时折行，不会拆开多字节字符
+func writeICSLine(buf *bytes.Buffer, content string) {
+	limit := icsLineSize
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		buf.WriteString(content[:cut])
+		buf.WriteString("\r\n ")
+		content = content[cut:]
+		// 续行开头的空格也计入长度
+		limit = icsLineSize - 1
+	}
+	buf.WriteString(content)
+	buf.WriteString("\r\n")
+}
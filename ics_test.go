@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestExportICSCanceledAndFolding(t *testing.T) {
+	course := &Course{
+		Name:    strings.Repeat("非常长的课程名称", 10),
+		Teacher: "李老师; 张老师, 王老师",
+		ScheduleRules: []CourseScheduleRule{
+			{Location: "旗山东1-201", StartClass: 9, EndClass: 11, StartWeek: 1, EndWeek: 4, Weekday: 4, Single: true, Double: true},
+		},
+		AdjustRules: []CourseAdjustRule{
+			{OldWeek: 2, OldWeekday: 4, OldStartClass: 9, OldEndClass: 11, Canceled: true},
+		},
+	}
+
+	// 学期开始日期不是周一时按所在周的周一计算
+	out, err := ExportICS([]*Course{course}, ICSOptions{TermStart: time.Date(2025, 2, 26, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ics := string(out)
+
+	if !strings.Contains(ics, "DTSTART;TZID=Asia/Shanghai:20250227T190000\r\n") ||
+		!strings.Contains(ics, "EXDATE;TZID=Asia/Shanghai:20250306T190000\r\n") {
+		t.Errorf("unexpected schedule:\n%s", ics)
+	}
+	if strings.Count(ics, "BEGIN:VEVENT") != 1 {
+		t.Errorf("canceled class should not create an event")
+	}
+	if !strings.Contains(ics, `李老师\; 张老师\, 王老师`) {
+		t.Errorf("text not escaped")
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if len(line) > 75 || !utf8.ValidString(line) {
+			t.Errorf("invalid folded line %q", line)
+		}
+	}
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	if !strings.Contains(unfolded, "SUMMARY:"+course.Name+"\r\n") {
+		t.Errorf("folded summary does not unfold to the course name")
+	}
+
+	if _, err = ExportICS([]*Course{course}, ICSOptions{}); err == nil {
+		t.Errorf("expected error without term start")
+	}
+}
+
+// 作息表中缺少节次的周次不生成课程，写入 EXDATE，RRULE 覆盖的其余周次不变
+func TestExportICSSkippedWeeks(t *testing.T) {
+	periods, _ := DefaultClassPeriods(CampusQiShan)
+	// 只有 2 月使用缺少晚上节次的作息
+	periods.Winter = periods.Summer[:2]
+	periods.SummerFrom = time.March
+	periods.WinterFrom = time.February
+
+	course := &Course{
+		Name:    "高等数学",
+		Teacher: "李老师",
+		ScheduleRules: []CourseScheduleRule{
+			{Location: "旗山东1-201", StartClass: 9, EndClass: 11, StartWeek: 1, EndWeek: 8, Weekday: 1, Single: true, Double: true},
+		},
+	}
+	out, err := ExportICS([]*Course{course}, ICSOptions{
+		TermStart:    time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC),
+		ClassPeriods: map[string]ClassPeriods{CampusQiShan: periods},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "DTSTART;TZID=Asia/Shanghai:20250120T190000\r\nDTEND;TZID=Asia/Shanghai:20250120T213500\r\n" +
+		"RRULE:FREQ=WEEKLY;INTERVAL=1;COUNT=8\r\n" +
+		"EXDATE;TZID=Asia/Shanghai:20250203T190000,20250210T190000,20250217T190000,20250224T190000\r\n"
+	if !strings.Contains(strings.ReplaceAll(string(out), "\r\n ", ""), expected) {
+		t.Errorf("unexpected schedule:\n%s", out)
+	}
+}
+
+// 调换教室后 UID 不变，重复导入时更新原来的事件
+func TestExportICSUIDIgnoresLocation(t *testing.T) {
+	uid := func(location string) string {
+		course := &Course{
+			Name:    "高等数学",
+			Teacher: "李老师",
+			ScheduleRules: []CourseScheduleRule{
+				{Location: location, StartClass: 1, EndClass: 2, StartWeek: 1, EndWeek: 4, Weekday: 1, Single: true, Double: true},
+			},
+		}
+		out, err := ExportICS([]*Course{course}, ICSOptions{TermStart: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(string(out), "\r\n") {
+			if strings.HasPrefix(line, "UID:") {
+				return line
+			}
+		}
+		t.Fatal("missing UID")
+		return ""
+	}
+	if uid("旗山东1-201") != uid("旗山东3-101") {
+		t.Errorf("UID should not depend on the location")
+	}
+}
@@ -18,54 +18,127 @@ package jwch
 
 import (
 	"bytes"
-	"crypto/tls"
-	"encoding/json"
+	"context"
+	"log/slog"
 	"net/http"
+	"net/http/cookiejar"
 	"strings"
+	"time"
 
 	"github.com/west2-online/jwch/constants"
 	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/parse"
+	"github.com/west2-online/jwch/selectors"
 
 	"github.com/antchfx/htmlquery"
 	"github.com/go-resty/resty/v2"
 	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
 )
 
-func NewStudent() *Student {
-	// 从环境变量加载配置
-	config := LoadConfigFromEnv()
+// NewStudent 创建学生对象，不传入 opts 时与之前的行为一致：
+// 从环境变量加载代理配置、跳过证书校验、禁用 HTTP/2 与重定向
+func NewStudent(opts ...Option) *Student {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	var client *resty.Client
+	if o.httpClient != nil {
+		// 在副本上组装 transport 链，避免修改调用方的 client，多个 Student 共用同一个 client 时也不会重复包装
+		// 登录凭证保存在 cookie 中，每个 Student 使用自己的 cookie jar，避免互相覆盖
+		c := *o.httpClient
+		c.Jar, _ = cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+		client = resty.NewWithClient(&c)
+	} else {
+		client = resty.New()
+	}
+
+	switch {
+	case o.transport != nil:
+		client.SetTransport(o.transport)
+	case o.httpClient == nil || o.httpClient.Transport == nil:
+		// 先确定代理池，再分别创建 Transport 和外层的代理选择
+		proxy := o.resolveProxy()
+		client.SetTransport(proxy.wrap(o.newTransport(proxy.pool != nil), logger))
+	}
+	registry := o.selectors
+	if registry == nil {
+		registry = selectors.DefaultRegistry
+	}
+
+	// 录制位于最内层，记录每次实际发出的请求
+	if o.recorder != nil {
+		client.SetTransport(o.recorder.transport(client.GetClient().Transport, registry))
+	}
+
+	// hook 位于限流器和重试之内，每次实际发出的请求都会触发
+	hooks := &hookChain{hooks: o.hooks}
+	client.SetTransport(&hookTransport{chain: hooks, next: client.GetClient().Transport})
+
+	// 每次重试都会重新经过限流器
+	if o.limiter != nil {
+		client.SetTransport(o.limiter.Transport(client.GetClient().Transport))
+	}
+	if o.retryPolicy != nil {
+		client.SetTransport(o.retryPolicy.transport(client.GetClient().Transport, logger))
+	}
+	// 最外层统一解码，重试和 hook 看到的仍然是原始响应
+	client.SetTransport(&decodeTransport{next: client.GetClient().Transport})
+
+	// 每个请求使用自己的计数，重试次数不写入响应头
+	client.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+		r.SetContext(withAttempts(r.Context()))
+		return nil
+	})
 
-	// Disable HTTP/2.0
 	// Disable Redirect
-	transport := &http.Transport{
-		TLSNextProto:    make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-
-	// 如果启用了代理，先获取隧道地址再设置代理
-	if config.Proxy.Enabled {
-		_, err := config.GetTunnelAddress()
-		if err == nil && config.Proxy.ProxyServer != "" {
-			proxyURL, err := config.GetProxyURL()
-			if err == nil {
-				transport.Proxy = http.ProxyURL(proxyURL)
-			}
-		}
+	client.SetRedirectPolicy(resty.NoRedirectPolicy())
+	if o.timeout > 0 {
+		client.SetTimeout(o.timeout)
+	}
+	if o.userAgent != "" {
+		client.SetHeader("User-Agent", o.userAgent)
+	}
+
+	endpoints := DefaultEndpoints()
+	if o.endpoints != nil {
+		endpoints = *o.endpoints
+	}
+	fanOut := defaultFanOutConcurrency
+	if o.fanOutSet {
+		fanOut = o.fanOut
 	}
 
-	client := resty.New().
-		SetTransport(transport).
-		SetRedirectPolicy(resty.NoRedirectPolicy())
+	s := &Student{
+		client:          client,
+		endpoints:       endpoints,
+		selectors:       registry,
+		logger:          logger,
+		captchaSolver:   o.captchaSolver,
+		captchaAttempts: defaultCaptchaAttempts,
+		autoRelogin:     o.autoRelogin,
 
-	return &Student{
-		client: client,
+		hooks:             hooks,
+		fanOutConcurrency: fanOut,
+		fanOutObserver:    o.fanOutObs,
+		cache:             o.cache,
 	}
+	if o.metrics != nil {
+		s.WithMetrics(o.metrics)
+	}
+	return s
 }
 
 func (s *Student) WithLoginData(identifier string, cookies []*http.Cookie) *Student {
-	s.Identifier = identifier
-	s.cookies = cookies
-	s.client.SetCookies(cookies)
+	s.SetIdentifier(identifier)
+	s.SetCookies(cookies)
 	return s
 }
 
@@ -76,16 +149,116 @@ func (s *Student) WithUser(id, password string) *Student {
 	return s
 }
 
+// WithCaptchaSolver 设置登录时使用的验证码识别器，为 nil 时使用西二服务器自动识别
+func (s *Student) WithCaptchaSolver(solver CaptchaSolver) *Student {
+	s.captchaSolver = solver
+	return s
+}
+
+// WithCaptchaAttempts 设置验证码被拒绝时最多尝试的次数
+func (s *Student) WithCaptchaAttempts(attempts int) *Student {
+	s.captchaAttempts = attempts
+	return s
+}
+
+// WithEndpoints 设置教务处各接口的地址
+func (s *Student) WithEndpoints(endpoints Endpoints) *Student {
+	s.endpoints = endpoints
+	return s
+}
+
+// WithBaseURL 将所有接口地址的主机替换为 baseURL（保留原地址的路径），用于对接本地的模拟服务器
+// baseURL 不合法时保持原有地址不变
+func (s *Student) WithBaseURL(baseURL string) *Student {
+	endpoints, err := s.endpoints.WithBaseURL(baseURL)
+	if err != nil {
+		return s
+	}
+	s.endpoints = endpoints
+	return s
+}
+
+// Endpoints 返回当前使用的接口地址
+func (s *Student) Endpoints() Endpoints {
+	return s.endpoints
+}
+
+// WithSelectors 设置解析页面使用的选择器，为 nil 时使用 selectors.DefaultRegistry
+// 同一个 Registry 可以在多个 Student 之间共享，通过 Registry.Load 在运行时替换
+func (s *Student) WithSelectors(registry *selectors.Registry) *Student {
+	if registry == nil {
+		registry = selectors.DefaultRegistry
+	}
+	s.selectors = registry
+	return s
+}
+
+// parser 返回使用当前选择器的 parse.Parser，一次解析应只取一次
+func (s *Student) parser() *parse.Parser {
+	return parse.New(s.selectors.Current())
+}
+
+// WithAutoRelogin 设置会话过期时是否自动重新登录并重放请求，需要先通过 WithUser 设置账号密码
+func (s *Student) WithAutoRelogin(enabled bool) *Student {
+	s.autoRelogin = enabled
+	return s
+}
+
+// WithHooks 添加请求的回调，BeforeRequest 按添加的顺序调用，AfterResponse 和 OnError 按相反的顺序调用
+func (s *Student) WithHooks(hooks ...Hook) *Student {
+	s.hooks.add(hooks...)
+	return s
+}
+
+// WithMetrics 记录每次公开操作的耗时和结果，collector 同时实现 RequestObserver 时还会记录每个 HTTP 请求
+// 不要对同一个 Student 多次调用，否则 HTTP 请求会被重复记录
+func (s *Student) WithMetrics(collector MetricsCollector) *Student {
+	s.metrics = collector
+	if obs, ok := collector.(RequestObserver); ok {
+		s.hooks.add(requestObserverHook(obs))
+	}
+	return s
+}
+
+// WithCache 缓存 policies 中列出的操作的结果，policies 为 nil 时使用 DefaultCachePolicies
+// 多个 Student 可以共享同一个 cache，与学生无关的结果会在它们之间共享；cache 为 nil 时关闭缓存
+func (s *Student) WithCache(cache Cache, policies map[string]CachePolicy) *Student {
+	s.cache = nil
+	if cache != nil {
+		s.cache = newStudentCache(cache, policies)
+	}
+	return s
+}
+
+// WithFanOutConcurrency 设置 GetEmptyRoom 等并发查询的最大 worker 数，<= 0 表示不限制
+func (s *Student) WithFanOutConcurrency(n int) *Student {
+	s.fanOutConcurrency = n
+	return s
+}
+
+// WithFanOutObserver 设置并发查询结束时的回调
+func (s *Student) WithFanOutObserver(observer FanOutObserver) *Student {
+	s.fanOutObserver = observer
+	return s
+}
+
 func (s *Student) SetIdentifier(identifier string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.Identifier = identifier
+	s.generation++
 }
 
 func (s *Student) SetCookies(cookies []*http.Cookie) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.cookies = cookies
 	s.client.SetCookies(cookies)
 }
 
 func (s *Student) ClearLoginData() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.cookies = []*http.Cookie{}
 	s.client.Cookies = []*http.Cookie{}
 }
@@ -95,92 +268,196 @@ func (s *Student) NewRequest() *resty.Request {
 }
 
 func (s *Student) GetWithIdentifier(url string) (*html.Node, error) {
-	resp, err := s.NewRequest().SetHeader("Referer", constants.JwchReferer).SetQueryParam("id", s.Identifier).Get(url)
+	return s.GetWithIdentifierCtx(context.Background(), url)
+}
+
+// GetWithIdentifierCtx 同 GetWithIdentifier，ctx 被取消或超时时会中止请求
+// 开启 WithAutoRelogin 后，会话过期时会重新登录并重放请求
+func (s *Student) GetWithIdentifierCtx(ctx context.Context, url string) (*html.Node, error) {
+	ctx = defaultOperation(ctx, "GetWithIdentifier")
+	node, generation, err := s.getWithIdentifier(ctx, url)
+	if !s.shouldReplayRequest(ctx, err) {
+		return node, withURL(err, url)
+	}
+	if err = s.relogin(ctx, generation); err != nil {
+		return nil, err
+	}
+	node, _, err = s.getWithIdentifier(ctx, url)
+	return node, withURL(err, url)
+}
+
+func (s *Student) getWithIdentifier(ctx context.Context, url string) (*html.Node, uint64, error) {
+	// 持有读锁，避免请求过程中登录数据被重新登录修改
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	generation := s.generation
+
+	resp, err := s.NewRequest().SetContext(ctx).SetHeader("Referer", s.endpoints.JwchReferer).SetQueryParam("id", s.Identifier).Get(url)
 	// 会话过期：会直接重定向，但我们禁用了重定向，所以会有error
 	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, generation, ctxErr
+		}
+		// 没有收到响应，是网络错误而不是会话过期（重试见 WithRetryPolicy）
+		if resp == nil || resp.RawResponse == nil {
+			return nil, generation, networkError(err)
+		}
 		// 由于评议在重定向后的页面上，所以我们需要处理重定向
-		if resp != nil && resp.StatusCode() == 302 {
+		if resp.StatusCode() == 302 {
 			redirectURL := resp.Header().Get("Location")
 			// 再次访问重定向后的URL(带prefix)
-			respRedirected, errRedirected := s.NewRequest().
-				SetHeader("Referer", constants.JwchReferer).
+			respRedirected, errRedirected := s.NewRequest().SetContext(ctx).
+				SetHeader("Referer", s.endpoints.JwchReferer).
 				SetQueryParam("id", s.Identifier).
-				Get(constants.JwchPrefix + redirectURL)
+				Get(s.endpoints.JwchPrefix + redirectURL)
 
 			if errRedirected != nil {
-				return nil, errno.CookieError
+				if ctxErr := contextError(ctx); ctxErr != nil {
+					return nil, generation, ctxErr
+				}
+				return nil, generation, errno.CookieError
 			}
-			if strings.Contains(string(respRedirected.Body()), "请先对任课教师进行测评") {
-				return nil, errno.EvaluationNotFoundError
+			if err := classifyResponse(string(respRedirected.Body()), nil); err != nil {
+				return nil, generation, err
 			}
 		}
-		return nil, errno.CookieError
+		return nil, generation, errno.CookieError
 	}
 
-	// 还有一种情况是 id 或 cookie 缺失或者解析错误 TODO: 判断条件有点简陋
-	if strings.Contains(string(resp.Body()), "重新登录") {
-		return nil, errno.CookieError
+	node, err := htmlquery.Parse(bytes.NewReader(resp.Body()))
+	if err != nil {
+		return nil, generation, errno.HTMLParseError.WithErr(err)
 	}
-	if strings.Contains(string(resp.Body()), "请先对任课教师进行测评") {
-		return nil, errno.EvaluationNotFoundError
+	// id 或 cookie 缺失、需要评议、系统维护、欠费等提示页面
+	if err = classifyResponse(string(resp.Body()), node); err != nil {
+		return nil, generation, err
 	}
-
-	return htmlquery.Parse(bytes.NewReader(resp.Body()))
+	if err = statusError(resp); err != nil {
+		return nil, generation, err
+	}
+	return node, generation, nil
 }
 
 // PostWithIdentifier returns parse tree for the resp of the request.
 func (s *Student) PostWithIdentifier(url string, formData map[string]string) (*html.Node, error) {
-	resp, err := s.NewRequest().SetHeader("Referer", constants.JwchReferer).SetQueryParam("id", s.Identifier).SetFormData(formData).Post(url)
+	return s.PostWithIdentifierCtx(context.Background(), url, formData)
+}
+
+// PostWithIdentifierCtx 同 PostWithIdentifier，ctx 被取消或超时时会中止请求
+// 开启 WithAutoRelogin 后，会话过期时会重新登录并重放请求，表单中的 VIEWSTATE 会替换为新会话下的值
+func (s *Student) PostWithIdentifierCtx(ctx context.Context, url string, formData map[string]string) (*html.Node, error) {
+	ctx = defaultOperation(ctx, "PostWithIdentifier")
+	node, generation, err := s.postWithIdentifier(ctx, url, formData)
+	if !s.shouldReplayRequest(ctx, err) {
+		return node, withURL(err, url)
+	}
+	if err = s.relogin(ctx, generation); err != nil {
+		return nil, err
+	}
+	if formData, err = s.refreshViewState(ctx, url, formData); err != nil {
+		return nil, withURL(err, url)
+	}
+	node, _, err = s.postWithIdentifier(ctx, url, formData)
+	return node, withURL(err, url)
+}
+
+func (s *Student) postWithIdentifier(ctx context.Context, url string, formData map[string]string) (*html.Node, uint64, error) {
+	// 持有读锁，避免请求过程中登录数据被重新登录修改
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	generation := s.generation
 
-	s.NewRequest().EnableTrace()
+	resp, err := s.NewRequest().SetContext(ctx).SetHeader("Referer", s.endpoints.JwchReferer).SetQueryParam("id", s.Identifier).SetFormData(formData).Post(url)
 	// 会话过期：会直接重定向，但我们禁用了重定向，所以会有error
 	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, generation, ctxErr
+		}
 		// 由于评议在重定向后的页面上，所以我们需要处理重定向
 		if resp != nil && resp.StatusCode() == 302 {
 			redirectURL := resp.Header().Get("Location")
 			// 再次访问重定向后的URL(带prefix)
-			respRedirected, errRedirected := s.NewRequest().
-				SetHeader("Referer", constants.JwchReferer).
+			respRedirected, errRedirected := s.NewRequest().SetContext(ctx).
+				SetHeader("Referer", s.endpoints.JwchReferer).
 				SetQueryParam("id", s.Identifier).
 				// 这里不确定应该Get还是Post，但目前Post Method没有会被评议卡的
-				Get(constants.JwchPrefix + redirectURL)
+				Get(s.endpoints.JwchPrefix + redirectURL)
 
 			if errRedirected != nil {
-				return nil, errno.JwchNetworkError.WithErr(err)
+				if ctxErr := contextError(ctx); ctxErr != nil {
+					return nil, generation, ctxErr
+				}
+				return nil, generation, networkError(errRedirected)
 			}
-			if strings.Contains(string(respRedirected.Body()), "请先对任课教师进行测评") {
-				return nil, errno.EvaluationNotFoundError
+			if err := classifyResponse(string(respRedirected.Body()), nil); err != nil {
+				return nil, generation, err
 			}
 		}
-		return nil, errno.JwchNetworkError.WithErr(err)
+		return nil, generation, networkError(err)
 	}
 
-	// id 或 cookie 缺失或者解析错误 TODO: 判断条件有点简陋
-	if strings.Contains(string(resp.Body()), "处理URL失败") {
-		return nil, errno.CookieError
+	node, err := htmlquery.Parse(strings.NewReader(strings.TrimSpace(string(resp.Body()))))
+	if err != nil {
+		return nil, generation, errno.HTMLParseError.WithErr(err)
+	}
+	// id 或 cookie 缺失、需要评议、系统维护、欠费等提示页面
+	if err = classifyResponse(string(resp.Body()), node); err != nil {
+		return nil, generation, err
 	}
-	if strings.Contains(string(resp.Body()), "请先对任课教师进行测评") {
-		return nil, errno.EvaluationNotFoundError
+	if err = statusError(resp); err != nil {
+		return nil, generation, err
 	}
-	return htmlquery.Parse(strings.NewReader(strings.TrimSpace(string(resp.Body()))))
+	return node, generation, nil
 }
 
-// GetValidateCode 获取验证码
-func GetValidateCode(image string) (string, error) {
-	// 请求西二服务器，自动识别验证码
-	code := verifyCodeResponse{}
+// userAgent 返回请求教务处官网时使用的 User-Agent，未通过 WithUserAgent 设置时使用浏览器的 User-Agent
+func (s *Student) userAgent() string {
+	if ua := s.client.Header.Get("User-Agent"); ua != "" {
+		return ua
+	}
+	return constants.UserAgent
+}
 
-	s := NewStudent()
-	resp, err := s.NewRequest().SetFormData(map[string]string{
-		"validateCode": image,
-	}).Post("https://statistics.fzuhelper.w2fzu.com/api/login/validateCode?validateCode")
-	if err != nil {
-		return "", errno.HTTPQueryError.WithMessage("automatic code identification failed")
+// contextError 在 ctx 已被取消或超时时返回 ContextCanceledError，否则返回 nil
+func contextError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return errno.ContextCanceledError.WithErr(err)
 	}
+	return nil
+}
 
-	err = json.Unmarshal(resp.Body(), &code)
-	if err != nil {
-		return "", errno.HTTPQueryError.WithErr(err)
+// withURL 为 err 记录请求地址，url 为不带 id 的接口地址
+func withURL(err error, url string) error {
+	if err == nil {
+		return nil
+	}
+	e := errno.ConvertErr(err)
+	if e.URL == "" {
+		e = e.WithURL(url)
+	}
+	return e
+}
+
+// finish 在公开方法返回前 defer 调用：将错误统一转换为 ErrNo 并记录操作名，然后记录指标
+// 嵌套调用时保留最先出错的操作名
+func (s *Student) finish(ctx context.Context, start time.Time, err *error) {
+	op := OperationFromContext(ctx)
+	if *err != nil {
+		e := errno.ConvertErr(*err)
+		if e.Op == "" {
+			e = e.WithOp(op)
+		}
+		*err = e
 	}
-	return code.Message, nil
+	if s.metrics != nil {
+		s.metrics.ObserveOperation(op, ClassifyOutcome(*err), time.Since(start))
+	}
+}
+
+// GetValidateCode 获取验证码
+func GetValidateCode(image string) (string, error) {
+	// 请求西二服务器，自动识别验证码
+	s := NewStudent()
+	solver := &RemoteCaptchaSolver{URL: constants.AutoCaptchaVerifyURL, Client: s.client}
+	return solver.solveEncoded(context.Background(), image)
 }
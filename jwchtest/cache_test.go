@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/jwchtest"
+)
+
+func TestCacheSharedBetweenStudents(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	cache := jwch.NewLRUCache(16)
+	stu, err := srv.NewLoggedInStudent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stu.WithCache(cache, nil)
+	want, err := stu.GetSchoolCalendar()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 校历与学生无关，另一个未登录的 Student 也可以直接命中
+	other := srv.NewStudent(jwch.WithCache(cache, nil)).WithUser("102300000", "other")
+	ctx, status := jwch.ContextWithCacheStatus(context.Background())
+	got, err := other.GetSchoolCalendarCtx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Hit || status.Stale || got.CurrentTerm != want.CurrentTerm || len(got.Terms) != len(want.Terms) {
+		t.Errorf("unexpected cache result %+v, status %+v", got, status)
+	}
+	if srv.Hits("/xl.asp") != 1 {
+		t.Errorf("expected 1 request, got %d", srv.Hits("/xl.asp"))
+	}
+
+	// ContextWithoutCache 跳过缓存
+	if _, err = stu.GetSchoolCalendarCtx(jwch.ContextWithoutCache(context.Background())); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Hits("/xl.asp") != 2 {
+		t.Errorf("expected cache bypass, got %d requests", srv.Hits("/xl.asp"))
+	}
+}
+
+func TestCachePerStudent(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	cache := jwch.NewLRUCache(16)
+	policies := map[string]jwch.CachePolicy{"GetLocateDate": {TTL: time.Hour, PerStudent: true}}
+	a := srv.NewStudent(jwch.WithCache(cache, policies)).WithUser("102300001", "a")
+	b := srv.NewStudent(jwch.WithCache(cache, policies)).WithUser("102300002", "b")
+	for _, stu := range []*jwch.Student{a, b, a} {
+		if _, err := stu.GetLocateDate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if srv.Hits("/week.asp") != 2 || cache.Len() != 2 {
+		t.Errorf("expected one entry per student, got %d requests and %d entries", srv.Hits("/week.asp"), cache.Len())
+	}
+}
+
+// cache 为 nil 时不缓存，与 (*Student).WithCache(nil, nil) 一致
+func TestCacheNil(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	stu := srv.NewStudent(jwch.WithCache(nil, nil))
+	for range 2 {
+		if _, err := stu.GetSchoolCalendar(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if srv.Hits("/xl.asp") != 2 {
+		t.Errorf("expected no caching, got %d requests", srv.Hits("/xl.asp"))
+	}
+}
+
+func TestCacheServesStale(t *testing.T) {
+	srv := jwchtest.NewServer()
+
+	policies := map[string]jwch.CachePolicy{"GetLocateDate": {TTL: time.Millisecond, StaleTTL: time.Hour}}
+	stu := srv.NewStudent(jwch.WithCache(jwch.NewLRUCache(16), policies))
+	want, err := stu.GetLocateDate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// 教务处不可用时返回旧数据并标记为 Stale
+	srv.Close()
+	ctx, status := jwch.ContextWithCacheStatus(context.Background())
+	got, err := stu.GetLocateDateCtx(ctx)
+	if err != nil {
+		t.Fatalf("expected stale result, got %v", err)
+	}
+	if *got != *want || !status.Hit || !status.Stale || status.Err == nil {
+		t.Errorf("unexpected stale result %+v, status %+v", got, status)
+	}
+
+	// 调用方取消时不返回旧数据
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = stu.GetLocateDateCtx(canceled); err == nil {
+		t.Errorf("expected canceled error")
+	}
+}
+
+func TestCacheBackgroundRevalidate(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	policies := map[string]jwch.CachePolicy{"GetLocateDate": {TTL: time.Millisecond, StaleTTL: time.Hour, Background: true}}
+	stu := srv.NewStudent(jwch.WithCache(jwch.NewLRUCache(16), policies))
+	if _, err := stu.GetLocateDate(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	ctx, status := jwch.ContextWithCacheStatus(context.Background())
+	if _, err := stu.GetLocateDateCtx(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Stale {
+		t.Errorf("expected stale result while revalidating, got %+v", status)
+	}
+	deadline := time.Now().Add(time.Second)
+	for srv.Hits("/week.asp") < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if srv.Hits("/week.asp") != 2 {
+		t.Errorf("expected background refresh, got %d requests", srv.Hits("/week.asp"))
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+	cache := jwch.NewLRUCache(2)
+	entry := &jwch.CacheEntry{Value: []byte(`"v"`), StoredAt: time.Now()}
+	cache.Set(ctx, "a", entry, 0)
+	cache.Set(ctx, "b", entry, 0)
+	cache.Get(ctx, "a")
+	cache.Set(ctx, "c", entry, 0)
+	if _, ok := cache.Get(ctx, "b"); ok {
+		t.Errorf("least recently used entry was not evicted")
+	}
+	if _, ok := cache.Get(ctx, "a"); !ok {
+		t.Errorf("recently used entry was evicted")
+	}
+
+	cache.Set(ctx, "d", entry, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get(ctx, "d"); ok {
+		t.Errorf("expired entry returned")
+	}
+	cache.Delete(ctx, "a")
+	if cache.Len() != 0 {
+		t.Errorf("expected empty cache, got %d entries", cache.Len())
+	}
+}
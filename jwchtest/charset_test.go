@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/west2-online/jwch/jwchtest"
+)
+
+// GB2312 页面在传输层统一转换为 UTF-8
+func TestResponseDecodedToUTF8(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+	stu := srv.NewStudent()
+
+	resp, err := stu.NewRequest().Get(stu.Endpoints().SchoolCalendarURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(resp.Body()), "当前学期：202501") {
+		t.Errorf("body not decoded: %q", resp.Body())
+	}
+	if ct := resp.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	// 没有 meta 标签时按 GB18030 解码
+	page := readFixture(t, "school_calendar")
+	srv.SetFixture("school_calendar", bytes.Replace(page, []byte(`<meta http-equiv="Content-Type" content="text/html; charset=gb2312">`), nil, 1))
+	calendar, err := stu.GetSchoolCalendar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calendar.CurrentTerm != "202501" {
+		t.Errorf("unexpected current term %q", calendar.CurrentTerm)
+	}
+}
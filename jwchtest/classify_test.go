@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+func TestResponseClassifier(t *testing.T) {
+	cases := []struct {
+		name    string
+		page    string
+		target  errno.ErrNo
+		message string
+	}{
+		{
+			name:    "alert",
+			page:    `<html><body><script language='javascript'>alert('当前不在查询时间内！');</script></body></html>`,
+			target:  errno.JwchAlertError,
+			message: "当前不在查询时间内！",
+		},
+		{
+			name:    "arrears",
+			page:    `<html><body><form id="form1"><script>window.alert( '你尚有学费未缴清，暂时不能查询！');</script></form></body></html>`,
+			target:  errno.TuitionArrearsError,
+			message: "你尚有学费未缴清，暂时不能查询！",
+		},
+		{
+			name:    "maintenance alert",
+			page:    `<html><body><script>alert("系统维护中，请稍后访问");</script></body></html>`,
+			target:  errno.MaintenanceError,
+			message: "系统维护中，请稍后访问",
+		},
+		{
+			name:    "maintenance title",
+			page:    `<html><head><title>系统维护通知</title></head><body>教务处系统正在升级</body></html>`,
+			target:  errno.MaintenanceError,
+			message: "系统维护通知",
+		},
+		{
+			name:   "login page",
+			page:   `<html><body><form action="logincheck.asp"><input name="muser"/><input name="passwd"/></form></body></html>`,
+			target: errno.CookieError,
+		},
+		{
+			name:   "evaluation",
+			page:   `<html><body>请先对任课教师进行测评</body></html>`,
+			target: errno.EvaluationNotFoundError,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv, stu := newLoggedIn(t)
+			srv.SetFixture("gpa", []byte(c.page))
+
+			_, err := stu.GetGPA()
+			var e errno.ErrNo
+			if !errors.Is(err, c.target) || !errors.As(err, &e) {
+				t.Fatalf("expected %v, got %v", c.target, err)
+			}
+			if c.message != "" && e.ErrorMsg != c.message {
+				t.Errorf("expected message %q, got %q", c.message, e.ErrorMsg)
+			}
+			if e.Op != "GetGPA" {
+				t.Errorf("unexpected op %q", e.Op)
+			}
+		})
+	}
+}
+
+func TestResponseClassifierIgnoresInlineAlerts(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+
+	// 正常页面中的确认框不是提示页面
+	page, err := stu.GetCredit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixture, err := os.ReadFile("testdata/credit.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.SetFixture("credit", bytes.Replace(fixture, []byte("</form>"),
+		[]byte(`<script>function check(){ alert('请选择学期'); }</script><a onclick="alert('确定？')">x</a></form>`), 1))
+	got, err := stu.GetCredit()
+	if err != nil {
+		t.Fatalf("inline alert treated as error page: %v", err)
+	}
+	if len(got) != len(page) {
+		t.Errorf("unexpected credit result %v", got)
+	}
+}
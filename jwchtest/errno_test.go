@@ -0,0 +1,47 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+func TestPublicMethodErrors(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+
+	// 解析失败返回 HTMLParseError，并记录操作名
+	srv.SetFixture("credit", []byte("<html><body></body></html>"))
+	_, err := stu.GetCredit()
+	var e errno.ErrNo
+	if !errors.As(err, &e) || !errors.Is(err, errno.HTMLParseError) || e.Op != "GetCredit" {
+		t.Errorf("unexpected error %#v", err)
+	}
+
+	// 会话过期时记录请求地址，地址中不包含 id
+	srv.ExpireSession()
+	_, err = stu.GetMarks()
+	if !errors.As(err, &e) || !errors.Is(err, errno.CookieError) || e.Op != "GetMarks" || e.URL == "" {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	if strings.Contains(e.URL, "id=") {
+		t.Errorf("url leaks identifier: %s", e.URL)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/west2-online/jwch"
+)
+
+func TestExamScheduleInResults(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+
+	terms, err := stu.GetTerms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	courses, err := stu.GetSemesterCourses(terms.Terms[0], terms.ViewState, terms.EventValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exam := courses[0].Exam; exam == nil || exam.Building != "东3" || exam.Room != "101" || exam.Start.Hour() != 14 {
+		t.Errorf("unexpected course exam: %+v", exam)
+	}
+
+	marks, err := stu.GetMarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exam := marks[0].Exam; exam == nil || exam.Start.Day() != 10 || exam.Room != "201" {
+		t.Errorf("unexpected mark exam: %+v", exam)
+	}
+
+	rooms, err := stu.GetExamRoom(jwch.ExamRoomReq{Term: "202401"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rooms[0].Exam == nil || rooms[0].Exam.Campus != "旗山校区" || rooms[1].Exam != nil {
+		t.Errorf("unexpected exam rooms: %+v %+v", rooms[0].Exam, rooms[1].Exam)
+	}
+
+	// 日期和时间保留页面原文，无法解析的行 Exam 为 nil，不影响其他考试
+	page := []byte(`<html><body><form><table id="ContentPlaceHolder1_DataList_xxk">` +
+		`<tr><td>课程名称</td><td>学分</td><td>任课教师</td><td>考试时间地点</td></tr>` +
+		`<tr onmouseover="x"><td>数据结构</td><td>3.0</td><td>王老师</td><td>2025年1月8日 8:30-10:30  旗山东3-101</td></tr>` +
+		`<tr onmouseover="x"><td>大学英语</td><td>2.0</td><td>李老师</td><td>2025年2月30日 8:30-10:30  旗山东3-102</td></tr>` +
+		`</table><input id="__VIEWSTATE" value="{{VIEWSTATE}}"><input id="__EVENTVALIDATION" value="{{EVENTVALIDATION}}">` +
+		`</form></body></html>`)
+	srv.SetFixture("exam_room", page)
+	rooms, err = stu.GetExamRoom(jwch.ExamRoomReq{Term: "202401"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rooms[0].Date != "2025年1月8日" || rooms[0].Time != "8:30-10:30" || rooms[0].Exam == nil || rooms[0].Exam.Start.Hour() != 8 {
+		t.Errorf("unexpected exam room: %+v", rooms[0])
+	}
+	if rooms[1].Date != "2025年2月30日" || rooms[1].Location != "旗山东3-102" || rooms[1].Exam != nil {
+		t.Errorf("unexpected malformed exam room: %+v", rooms[1])
+	}
+
+	// 格式错误的行返回错误而不是 panic
+	srv.SetFixture("exam_room", bytes.Replace(page, []byte(`<td>2.0</td><td>李老师</td>`), nil, 1))
+	if _, err = stu.GetExamRoom(jwch.ExamRoomReq{Term: "202401"}); err == nil {
+		t.Errorf("expected error for short row")
+	}
+}
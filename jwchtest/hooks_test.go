@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/jwchtest"
+)
+
+// recordingHook 记录所有回调
+type recordingHook struct {
+	mu        sync.Mutex
+	before    []jwch.RequestInfo
+	responses []jwch.ResponseInfo
+	errors    []jwch.ResponseInfo
+}
+
+func (r *recordingHook) Hook() jwch.Hook {
+	return jwch.Hook{
+		BeforeRequest: func(ctx context.Context, req *jwch.RequestInfo) context.Context {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.before = append(r.before, *req)
+			return nil
+		},
+		AfterResponse: func(ctx context.Context, resp *jwch.ResponseInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.responses = append(r.responses, *resp)
+		},
+		OnError: func(ctx context.Context, resp *jwch.ResponseInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.errors = append(r.errors, *resp)
+		},
+	}
+}
+
+func TestHooks(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	rec := &recordingHook{}
+	stu := srv.NewStudent(jwch.WithHooks(rec.Hook()))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stu.GetMarks(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.before) == 0 || len(rec.before) != len(rec.responses) || len(rec.errors) != 0 {
+		t.Fatalf("unexpected callbacks: %d before, %d responses, %d errors", len(rec.before), len(rec.responses), len(rec.errors))
+	}
+	ops := map[string]bool{}
+	var redirects, marks int
+	for _, resp := range rec.responses {
+		ops[resp.Op] = true
+		if resp.StatusCode == 302 {
+			redirects++
+		}
+		if resp.Op == "GetMarks" && resp.StatusCode == 200 {
+			marks++
+			if resp.BytesReceived == 0 || resp.Latency <= 0 {
+				t.Errorf("missing size or latency: %+v", resp)
+			}
+		}
+		// 不能泄露会话标识、学号和密码
+		for _, secret := range []string{stu.Identifier, srv.StudentID, srv.Password} {
+			if strings.Contains(resp.URL, secret) {
+				t.Errorf("url %q leaks %q", resp.URL, secret)
+			}
+		}
+	}
+	if !ops["Login"] || !ops["GetMarks"] || marks == 0 || redirects == 0 {
+		t.Errorf("unexpected responses: ops %v, %d marks, %d redirects", ops, marks, redirects)
+	}
+
+	// 创建后添加的 hook 同样生效
+	late := &recordingHook{}
+	stu.WithHooks(late.Hook())
+	if _, err := stu.GetInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if len(late.responses) == 0 || late.responses[0].Op != "GetInfo" || !strings.Contains(late.responses[0].URL, "id=REDACTED") {
+		t.Errorf("unexpected late hook responses %+v", late.responses)
+	}
+}
+
+func TestHooksOnError(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	rec := &recordingHook{}
+	flaky := &flakyTransport{path: "/xl.asp", failures: 1}
+	stu := srv.NewStudent(jwch.WithTransport(flaky), jwch.WithRetryPolicy(fastRetryPolicy()), jwch.WithHooks(rec.Hook()))
+	if _, err := stu.GetSchoolCalendar(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每次重试都会触发回调
+	if len(rec.errors) != 1 || rec.errors[0].Err == nil || rec.errors[0].Op != "GetSchoolCalendar" {
+		t.Errorf("unexpected errors %+v", rec.errors)
+	}
+	if len(rec.responses) != 1 || rec.responses[0].StatusCode != 200 {
+		t.Errorf("unexpected responses %+v", rec.responses)
+	}
+}
+
+func TestSlogHook(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	stu := srv.NewStudent(jwch.WithHooks(jwch.SlogHook(logger, slog.LevelDebug)))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stu.GetMarks(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"op":"GetMarks"`) || !strings.Contains(out, `"status":200`) {
+		t.Errorf("unexpected log output:\n%s", out)
+	}
+	_, cookies, err := stu.GetIdentifierAndCookies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := []string{stu.Identifier, srv.StudentID, srv.Password}
+	for _, c := range cookies {
+		secrets = append(secrets, c.Value)
+	}
+	for _, secret := range secrets {
+		if strings.Contains(out, secret) {
+			t.Errorf("log leaks %q", secret)
+		}
+	}
+}
+
+type fakeSpan struct {
+	name  string
+	attrs map[string]any
+	err   error
+	ended int
+}
+
+func (s *fakeSpan) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *fakeSpan) RecordError(err error)              { s.err = err }
+func (s *fakeSpan) End()                               { s.ended++ }
+
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string) (context.Context, jwch.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &fakeSpan{name: name, attrs: map[string]any{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestTracingHook(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	tracer := &fakeTracer{}
+	flaky := &flakyTransport{path: "/xl.asp", failures: 1}
+	stu := srv.NewStudent(jwch.WithTransport(flaky), jwch.WithRetryPolicy(fastRetryPolicy()), jwch.WithHooks(jwch.TracingHook(tracer)))
+	if _, err := stu.GetSchoolCalendar(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(tracer.spans))
+	}
+	failed, ok := tracer.spans[0], tracer.spans[1]
+	if failed.name != "GetSchoolCalendar" || failed.err == nil || failed.ended != 1 {
+		t.Errorf("unexpected failed span %+v", failed)
+	}
+	if ok.ended != 1 || ok.attrs["http.response.status_code"] != 200 || ok.attrs["http.request.method"] != "GET" {
+		t.Errorf("unexpected span %+v", ok)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/west2-online/jwch"
+)
+
+func exportFixtureICS(t *testing.T) string {
+	t.Helper()
+	_, stu := newLoggedIn(t)
+
+	terms, err := stu.GetTerms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	courses, err := stu.GetSemesterCourses(terms.Terms[0], terms.ViewState, terms.EventValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calendar, err := stu.GetSchoolCalendar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, err := calendar.TermStartDate(terms.Terms[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := jwch.ICSOptions{TermStart: start, CalendarName: "课表", DTStamp: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)}
+	out, err := jwch.ExportICS(courses, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := jwch.ExportICS(courses, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, again) {
+		t.Errorf("export is not deterministic")
+	}
+	return string(out)
+}
+
+func TestExportICS(t *testing.T) {
+	ics := exportFixtureICS(t)
+
+	expected := []string{
+		// 数据结构 每周一 3-4 节，第 6 周调至第 9 周周五 7-8 节
+		"DTSTART;TZID=Asia/Shanghai:20250901T102000\r\nDTEND;TZID=Asia/Shanghai:20250901T120000\r\nRRULE:FREQ=WEEKLY;INTERVAL=1;COUNT=16\r\nEXDATE;TZID=Asia/Shanghai:20251006T102000\r\n",
+		"DTSTART;TZID=Asia/Shanghai:20251031T155000\r\nDTEND;TZID=Asia/Shanghai:20251031T173000\r\nSUMMARY:数据结构（调课）\r\nLOCATION:旗山东3-101\r\n",
+		// 数据结构 单周周三 1-2 节
+		"DTSTART;TZID=Asia/Shanghai:20250903T082000\r\nDTEND;TZID=Asia/Shanghai:20250903T100000\r\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=8\r\n",
+		// 军事技能 第 3-4 周整周
+		"DTSTART;VALUE=DATE:20250915\r\nDTEND;VALUE=DATE:20250929\r\nSUMMARY:军事技能\r\n",
+		// 大学英语 双周周二 5-6 节，铜盘校区作息，10 月起改为冬季作息
+		"DTSTART;TZID=Asia/Shanghai:20250909T143000\r\nDTEND;TZID=Asia/Shanghai:20250909T161000\r\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=2\r\n",
+		"DTSTART;TZID=Asia/Shanghai:20251007T140000\r\nDTEND;TZID=Asia/Shanghai:20251007T154000\r\nRRULE:FREQ=WEEKLY;INTERVAL=2;COUNT=6\r\n",
+		"DESCRIPTION:任课教师：王老师\\n第3-4节\r\n",
+		"X-WR-CALNAME:课表\r\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(ics, e) {
+			t.Errorf("missing %q", e)
+		}
+	}
+
+	if n := strings.Count(ics, "BEGIN:VEVENT"); n != 6 {
+		t.Errorf("expected 6 events, got %d", n)
+	}
+	uids := map[string]bool{}
+	for _, line := range strings.Split(ics, "\r\n") {
+		if strings.HasPrefix(line, "UID:") {
+			uids[line] = true
+		}
+	}
+	if len(uids) != 6 {
+		t.Errorf("expected 6 distinct UIDs, got %d", len(uids))
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/constants"
+	"github.com/west2-online/jwch/jwchtest"
+)
+
+func TestFanOutWithSharedLimiter(t *testing.T) {
+	limiter := jwch.NewLimiter(0, 1, 2)
+	var (
+		mu    sync.Mutex
+		stats []jwch.FanOutStats
+	)
+	observer := func(s jwch.FanOutStats) {
+		mu.Lock()
+		defer mu.Unlock()
+		stats = append(stats, s)
+	}
+
+	req := jwch.EmptyRoomReq{Campus: "旗山校区", Time: "2025-03-01", Start: "1", End: "2"}
+	var wg sync.WaitGroup
+	results := make([][]string, 2)
+	for i := range results {
+		// 模拟服务器只保留一个会话，每个 Student 使用各自的服务器，共享同一个 Limiter
+		srv := jwchtest.NewServer()
+		defer srv.Close()
+		stu := srv.NewStudent(jwch.WithLimiter(limiter), jwch.WithFanOutConcurrency(3), jwch.WithFanOutObserver(observer))
+		if err := stu.Login(); err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rooms, err := stu.GetQiShanEmptyRoom(req)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = rooms
+		}(i)
+	}
+	wg.Wait()
+
+	if len(results[0]) != 2*len(constants.BuildingArray) || !reflect.DeepEqual(results[0], results[1]) {
+		t.Errorf("unexpected rooms %v / %v", results[0], results[1])
+	}
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 fan-out reports, got %d", len(stats))
+	}
+	for _, s := range stats {
+		if s.Op != "GetQiShanEmptyRoom" || s.Tasks != len(constants.BuildingArray) || s.Workers != 3 || s.Err != nil {
+			t.Errorf("unexpected fan-out stats %+v", s)
+		}
+		// 8 个任务只有 3 个 worker，后面的任务必然需要排队
+		if s.MaxQueueDelay <= 0 || s.MaxQueueDelay < s.QueueDelay {
+			t.Errorf("unexpected queue delay %+v", s)
+		}
+	}
+	if ls := limiter.Stats(); ls.InFlight != 0 || ls.Requests == 0 || ls.MaxWait <= 0 {
+		t.Errorf("unexpected limiter stats %+v", ls)
+	}
+}
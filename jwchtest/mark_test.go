@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"testing"
+
+	"github.com/west2-online/jwch/model"
+)
+
+func TestGetMarksV2(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	marks, err := stu.GetMarksV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(marks) != 4 {
+		t.Fatalf("expected 4 marks, got %d", len(marks))
+	}
+	m := marks[0]
+	if m.Type != model.MarkTypeMajor || m.ElectiveType != model.ElectiveRequired || m.ExamType != model.ExamTypeExam {
+		t.Errorf("unexpected enums: %+v", m)
+	}
+	if m.Credits != 5 || m.GPA != 4 || m.EarnedCredits != 5 || m.Score.Kind != model.ScoreNumeric || m.Score.Value != 92 {
+		t.Errorf("unexpected values: %+v", m)
+	}
+
+	kinds := []model.ScoreKind{model.ScoreNumeric, model.ScoreFiveLevel, model.ScorePassFail, model.ScoreAbsent}
+	for i, kind := range kinds {
+		if marks[i].Score.Kind != kind {
+			t.Errorf("mark %d: expected %s, got %+v", i, kind, marks[i].Score)
+		}
+	}
+	if marks[1].ExamType != model.ExamTypeAssessment || marks[1].Score.HasValue() || !marks[1].Score.Passed || !marks[2].Score.Passed || marks[3].Score.Passed {
+		t.Errorf("unexpected marks: %+v %+v %+v", marks[1], marks[2], marks[3])
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/jwchtest"
+)
+
+func TestMetrics(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	metrics := jwch.NewMetrics()
+	flaky := &flakyTransport{path: "/xl.asp", failures: 1}
+	stu := srv.NewStudent(jwch.WithTransport(flaky), jwch.WithMetrics(metrics))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stu.GetMarks(); err != nil {
+		t.Fatal(err)
+	}
+	srv.RequireEvaluation(true)
+	if _, err := stu.GetMarks(); err == nil {
+		t.Fatal("expected EvaluationNotFoundError")
+	}
+	srv.RequireEvaluation(false)
+	srv.ExpireSession()
+	if _, err := stu.GetMarks(); err == nil {
+		t.Fatal("expected CookieError")
+	}
+	if _, err := stu.GetSchoolCalendar(); err == nil {
+		t.Fatal("expected network error")
+	}
+
+	counts := map[string]uint64{}
+	for _, s := range metrics.Snapshot().Operations {
+		counts[s.Op+"/"+string(s.Outcome)] = s.Count
+		if s.Buckets[len(s.Buckets)-1] > s.Count {
+			t.Errorf("bucket count exceeds total: %+v", s)
+		}
+	}
+	want := map[string]uint64{
+		"Login/success":                   1,
+		"GetMarks/success":                1,
+		"GetMarks/evaluation_not_found":   1,
+		"GetMarks/cookie_error":           1,
+		"GetSchoolCalendar/network_error": 1,
+	}
+	for key, n := range want {
+		if counts[key] != n {
+			t.Errorf("%s: got %d, want %d (all: %v)", key, counts[key], n, counts)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE jwch_operations_total counter",
+		`jwch_operations_total{op="GetMarks",outcome="cookie_error"} 1`,
+		`jwch_operation_duration_seconds_bucket{op="Login",outcome="success",le="+Inf"} 1`,
+		`jwch_operation_duration_seconds_count{op="Login",outcome="success"} 1`,
+		`jwch_http_requests_total{op="GetMarks",code="302"} 2`,
+		`jwch_http_requests_total{op="GetSchoolCalendar",code="error"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+}
+
+// 验证码识别服务不可用属于上游异常，不能计为解析失败
+func TestClassifyCaptchaServiceFailure(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+	captcha := httptest.NewServer(nil)
+	captcha.Close()
+
+	metrics := jwch.NewMetrics()
+	stu := srv.NewStudent(jwch.WithCaptchaSolver(&jwch.RemoteCaptchaSolver{URL: captcha.URL}), jwch.WithMetrics(metrics))
+	err := stu.Login()
+	if err == nil {
+		t.Fatal("expected login error")
+	}
+	if got := jwch.ClassifyOutcome(err); got != jwch.OutcomeNetworkError {
+		t.Errorf("expected %s, got %s for %v", jwch.OutcomeNetworkError, got, err)
+	}
+	var out strings.Builder
+	if err = metrics.WritePrometheus(&out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), `outcome="parse_error"`) {
+		t.Errorf("captcha failure counted as parse error:\n%s", out.String())
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/jwchtest"
+)
+
+type countingTransport struct {
+	count atomic.Int32
+	next  http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.count.Add(1)
+	return t.next.RoundTrip(req)
+}
+
+func TestOptionTransport(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	transport := &countingTransport{next: http.DefaultTransport}
+	stu := srv.NewStudent(jwch.WithTransport(transport))
+	other := srv.NewStudent()
+
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stu.GetMarks(); err != nil {
+		t.Fatal(err)
+	}
+	loginAndMarks := transport.count.Load()
+	if loginAndMarks == 0 {
+		t.Fatalf("custom transport not used")
+	}
+
+	// 另一个 Student 使用默认配置，不会经过 transport
+	if err := other.Login(); err != nil {
+		t.Fatal(err)
+	}
+	if transport.count.Load() != loginAndMarks {
+		t.Errorf("students share transport")
+	}
+}
+
+func TestOptionUserAgentAndTimeout(t *testing.T) {
+	var userAgent atomic.Value
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent.Store(r.UserAgent())
+		if r.URL.Path == "/week.asp" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(`<ul class="list-gl"></ul>`))
+	}))
+	defer slow.Close()
+
+	stu := jwch.NewStudent(
+		jwch.WithConfig(nil),
+		jwch.WithUserAgent("jwch-test"),
+		jwch.WithTimeout(50*time.Millisecond),
+	).WithBaseURL(slow.URL)
+
+	_, _, _ = stu.GetNoticeInfo(&jwch.NoticeInfoReq{PageNum: 1})
+	if ua, _ := userAgent.Load().(string); ua != "jwch-test" {
+		t.Errorf("unexpected user agent: %q", ua)
+	}
+
+	if _, err := stu.GetLocateDate(); err == nil {
+		t.Errorf("expected timeout error")
+	}
+}
+
+func TestOptionHTTPClient(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	transport := &countingTransport{next: http.DefaultTransport}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: transport, Jar: jar}
+	var hooked atomic.Int32
+	hook := jwch.Hook{BeforeRequest: func(ctx context.Context, req *jwch.RequestInfo) context.Context {
+		hooked.Add(1)
+		return ctx
+	}}
+	stu := srv.NewStudent(jwch.WithHTTPClient(client), jwch.WithHooks(hook))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	if transport.count.Load() == 0 {
+		t.Errorf("custom client not used")
+	}
+
+	// 登录流程依赖不跟随重定向
+	if !strings.HasPrefix(stu.Identifier, "20250901") {
+		t.Errorf("unexpected identifier: %s", stu.Identifier)
+	}
+
+	// 调用方的 client 不会被修改，共用 client 的另一个 Student 不会触发前一个 Student 的 hook
+	if client.Transport != transport || client.CheckRedirect != nil {
+		t.Fatalf("caller's client modified: %T", client.Transport)
+	}
+	// 每个 Student 使用自己的 cookie jar，共用 client 时不会互相覆盖会话
+	u, _ := url.Parse(srv.URL)
+	if cookies := jar.Cookies(u); len(cookies) != 0 {
+		t.Errorf("session cookies stored in the caller's jar: %v", cookies)
+	}
+	hookedBefore := hooked.Load()
+	if err := srv.NewStudent(jwch.WithHTTPClient(client)).Login(); err != nil {
+		t.Fatal(err)
+	}
+	if hooked.Load() != hookedBefore {
+		t.Errorf("hooks of another student fired")
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/constants"
+	"github.com/west2-online/jwch/parse"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name + ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// 离线解析保存下来的页面，结果与在线请求一致
+func TestParseOffline(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	check := func(name string, online, offline any, errOnline, errOffline error) {
+		t.Helper()
+		if errOnline != nil || errOffline != nil {
+			t.Errorf("%s: online error %v, offline error %v", name, errOnline, errOffline)
+			return
+		}
+		if !reflect.DeepEqual(online, offline) {
+			t.Errorf("%s: offline result differs\nonline:  %+v\noffline: %+v", name, online, offline)
+		}
+	}
+
+	marks, err := stu.GetMarks()
+	offlineMarks, offlineErr := parse.ParseMarks(bytes.NewReader(readFixture(t, "marks")))
+	check("marks", marks, offlineMarks, err, offlineErr)
+
+	credits, err := stu.GetCredit()
+	offlineCredits, offlineErr := parse.ParseCredit(bytes.NewReader(readFixture(t, "credit")))
+	check("credit", credits, offlineCredits, err, offlineErr)
+
+	gpa, err := stu.GetGPA()
+	offlineGPA, offlineErr := parse.ParseGPA(bytes.NewReader(readFixture(t, "gpa")))
+	check("gpa", gpa, offlineGPA, err, offlineErr)
+
+	info, err := stu.GetInfo()
+	offlineInfo, offlineErr := parse.ParseInfo(bytes.NewReader(readFixture(t, "student_info")))
+	check("info", info, offlineInfo, err, offlineErr)
+
+	lectures, err := stu.GetLectures()
+	offlineLectures, offlineErr := parse.ParseLectures(bytes.NewReader(readFixture(t, "lectures")))
+	check("lectures", lectures, offlineLectures, err, offlineErr)
+
+	rooms, err := stu.GetExamRoom(jwch.ExamRoomReq{Term: "202401"})
+	offlineRooms, offlineErr := parse.ParseExamRooms(bytes.NewReader(readFixture(t, "exam_room")))
+	check("exam rooms", rooms, offlineRooms, err, offlineErr)
+
+	cet, err := stu.GetCET()
+	offlineCET, offlineErr := parse.ParseUnifiedExams(bytes.NewReader(readFixture(t, "cet")))
+	check("cet", cet, offlineCET, err, offlineErr)
+
+	// 离线解析时课程大纲的链接使用教务处的地址补全
+	courses, err := parse.ParseCourses(bytes.NewReader(readFixture(t, "courses")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(courses) != 3 || courses[0].Syllabus != constants.JwchPrefix+"/pyfa/kcdg/kcdg_view.aspx?kcdm=10010" {
+		t.Errorf("unexpected courses: %+v", courses)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/jwchtest"
+	"github.com/west2-online/jwch/utils"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	recorder := jwch.NewRecorder()
+	stu := srv.NewStudent(jwch.WithRecorder(recorder))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	identifier, cookies, err := stu.GetIdentifierAndCookies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := stu.GetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	marks, err := stu.GetMarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	terms, err := stu.GetTerms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	courses, err := stu.GetSemesterCourses(terms.Terms[0], terms.ViewState, terms.EventValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calendar, err := stu.GetSchoolCalendar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := stu.GetTermEvents(calendar.Terms[0].TermId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = recorder.WriteHAR(&buf); err != nil {
+		t.Fatal(err)
+	}
+	archive := buf.String()
+
+	var har struct {
+		Log struct {
+			Entries []json.RawMessage `json:"entries"`
+		} `json:"log"`
+	}
+	if err = json.Unmarshal(buf.Bytes(), &har); err != nil {
+		t.Fatal(err)
+	}
+	if len(har.Log.Entries) != recorder.Len() || recorder.Len() == 0 {
+		t.Errorf("expected %d entries, got %d", recorder.Len(), len(har.Log.Entries))
+	}
+
+	secrets := []string{srv.StudentID, utils.Md5Hash(srv.Password, 16), identifier, info.Name, info.Phone}
+	for _, c := range cookies {
+		secrets = append(secrets, c.Value)
+	}
+	for _, secret := range secrets {
+		if secret != "" && strings.Contains(archive, secret) {
+			t.Errorf("archive contains %q", secret)
+		}
+	}
+
+	// 关闭模拟服务器后，完全依靠录制的内容回放
+	srv.Close()
+	replay, err := jwch.NewReplayTransport(strings.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := jwch.NewStudent(jwch.WithConfig(nil), jwch.WithTransport(replay), jwch.WithEndpoints(stu.Endpoints())).
+		WithUser(jwch.Redacted, "")
+	if err = replayed.Login(); err != nil {
+		t.Fatal(err)
+	}
+	if err = replayed.CheckSession(); err != nil {
+		t.Errorf("CheckSession: %v", err)
+	}
+
+	replayedInfo, err := replayed.GetInfo()
+	if err != nil || replayedInfo.Name != jwch.Redacted || replayedInfo.Sex != jwch.Redacted || replayedInfo.College != jwch.Redacted {
+		t.Errorf("personal fields not redacted: %+v, %v", replayedInfo, err)
+	}
+	replayedMarks, err := replayed.GetMarks()
+	if err != nil || !reflect.DeepEqual(replayedMarks, marks) {
+		t.Errorf("unexpected marks: %v", err)
+	}
+	replayedCourses, err := replayed.GetSemesterCourses(terms.Terms[0], terms.ViewState, terms.EventValidation)
+	if err != nil || !reflect.DeepEqual(replayedCourses, courses) {
+		t.Errorf("unexpected courses: %v", err)
+	}
+	replayedEvents, err := replayed.GetTermEvents(calendar.Terms[0].TermId)
+	if err != nil || !reflect.DeepEqual(replayedEvents, events) {
+		t.Errorf("unexpected term events: %+v, %v", replayedEvents, err)
+	}
+
+	// 没有录制的请求
+	if _, err = replayed.GetCET(); !errors.Is(err, errno.HTTPQueryError) {
+		t.Errorf("expected HTTPQueryError, got %v", err)
+	}
+}
+
+func TestReplayInvalidArchive(t *testing.T) {
+	if _, err := jwch.NewReplayTransport(strings.NewReader(`{"log": `)); !errors.Is(err, errno.ParamError) {
+		t.Errorf("expected ParamError, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/jwchtest"
+	"github.com/west2-online/jwch/selectors"
+)
+
+func TestAutoRelogin(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	stu := srv.NewStudent(jwch.WithAutoRelogin(true))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.ExpireSession()
+	marks, err := stu.GetMarks()
+	if err != nil {
+		t.Fatalf("GetMarks after expiry: %v", err)
+	}
+	if len(marks) != 4 {
+		t.Errorf("expected 4 marks, got %d", len(marks))
+	}
+	if srv.Logins() != 2 || stu.Identifier != srv.Identifier() {
+		t.Errorf("expected one relogin, logins=%d identifier=%s", srv.Logins(), stu.Identifier)
+	}
+}
+
+func TestAutoReloginPostback(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	stu := srv.NewStudent().WithAutoRelogin(true)
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	terms, err := stu.GetTerms()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 过期后旧的 VIEWSTATE 也随之失效，重放时需要重新获取
+	srv.ExpireSession()
+	courses, err := stu.GetSemesterCourses(terms.Terms[0], terms.ViewState, terms.EventValidation)
+	if err != nil {
+		t.Fatalf("GetSemesterCourses after expiry: %v", err)
+	}
+	if len(courses) != 3 {
+		t.Errorf("expected 3 courses, got %d", len(courses))
+	}
+}
+
+// 多步回发的过程中会话过期时从头重新执行，而不是只重放最后一次回发
+func TestAutoReloginEmptyRoom(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	stu := srv.NewStudent(jwch.WithAutoRelogin(true), jwch.WithFanOutConcurrency(1))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 第 1 次请求获取页面，第 2 次选择校区，第 3 次查询教室时会话已过期
+	srv.ExpireSessionAfter("/kkgl/kbcx/kbcx_kjs.aspx", 2)
+	req := jwch.EmptyRoomReq{Campus: "铜盘校区", Time: "2025-03-01", Start: "1", End: "2"}
+	rooms, err := stu.GetEmptyRoom(req)
+	if err != nil {
+		t.Fatalf("GetEmptyRoom after expiry: %v", err)
+	}
+	expected := append(jwchtest.EmptyRooms(req.Campus, "", "多媒体教室"), jwchtest.EmptyRooms(req.Campus, "", "普通教室")...)
+	if !reflect.DeepEqual(rooms, expected) {
+		t.Errorf("unexpected rooms: %v", rooms)
+	}
+	if srv.Logins() != 2 {
+		t.Errorf("expected one relogin, got %d logins", srv.Logins())
+	}
+}
+
+// 重新获取 VIEWSTATE 时使用运行时的选择器
+func TestAutoReloginPostbackSelectors(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	registry := selectors.NewRegistry()
+	stu := srv.NewStudent(jwch.WithSelectors(registry), jwch.WithAutoRelogin(true))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	terms, err := stu.GetTerms()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = registry.Load([]byte(`{"xpath": {"form.viewstate": "//input[@id='missing']"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	srv.ExpireSession()
+	_, err = stu.GetSemesterCourses(terms.Terms[0], terms.ViewState, terms.EventValidation)
+	var e errno.ErrNo
+	if !errors.As(err, &e) || !errors.Is(err, errno.HTMLParseError) || e.ErrorMsg != "refresh __VIEWSTATE failed" {
+		t.Errorf("expected refresh to use the registry, got %v", err)
+	}
+}
+
+func TestAutoReloginConcurrent(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	stu := srv.NewStudent(jwch.WithAutoRelogin(true))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	srv.ExpireSession()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := stu.GetCredit()
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetCredit: %v", err)
+		}
+	}
+	if srv.Logins() != 2 {
+		t.Errorf("expected a single relogin, got %d logins", srv.Logins())
+	}
+}
+
+func TestAutoReloginWithoutCredentials(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	stu, err := srv.NewLoggedInStudent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stu.WithAutoRelogin(true).WithUser("", "")
+	srv.ExpireSession()
+
+	if _, err := stu.GetMarks(); !errors.Is(err, errno.CookieError) {
+		t.Errorf("expected CookieError, got %v", err)
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("unexpected relogin")
+	}
+}
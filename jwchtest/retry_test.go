@@ -0,0 +1,176 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/jwchtest"
+)
+
+// flakyTransport 对指定路径（和方法）的前 failures 次请求返回连接被重置的错误
+type flakyTransport struct {
+	mu       sync.Mutex
+	path     string
+	method   string // 为空时匹配所有方法
+	failures int
+	calls    int
+	next     http.RoundTripper // 为 nil 时使用 http.DefaultTransport
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	fail := false
+	if req.URL.Path == f.path && (f.method == "" || req.Method == f.method) {
+		f.calls++
+		fail = f.calls <= f.failures
+	}
+	f.mu.Unlock()
+	if fail {
+		return nil, errors.New("connection reset by peer")
+	}
+	if f.next != nil {
+		return f.next.RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (f *flakyTransport) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// unavailableTransport 对指定路径的请求都返回 503
+type unavailableTransport struct {
+	path  string
+	calls atomic.Int32
+}
+
+func (u *unavailableTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Path != u.path {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	u.calls.Add(1)
+	return &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Status:     "503 Service Unavailable",
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       io.NopCloser(strings.NewReader("<html><body>Service Unavailable</body></html>")),
+		Request:    req,
+	}, nil
+}
+
+func fastRetryPolicy() jwch.RetryPolicy {
+	policy := jwch.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	return policy
+}
+
+func TestRetryGet(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	flaky := &flakyTransport{path: "/xl.asp", failures: 2}
+	stu := srv.NewStudent(jwch.WithTransport(flaky), jwch.WithRetryPolicy(fastRetryPolicy()))
+	if _, err := stu.GetSchoolCalendar(); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if flaky.Calls() != 3 {
+		t.Errorf("expected 3 attempts, got %d", flaky.Calls())
+	}
+
+	// 重试次数用尽后返回 JwchNetworkError，并记录尝试次数
+	flaky = &flakyTransport{path: "/xl.asp", failures: 10}
+	stu = srv.NewStudent(jwch.WithTransport(flaky), jwch.WithRetryPolicy(fastRetryPolicy()))
+	_, err := stu.GetSchoolCalendar()
+	if e := errno.ConvertErr(err); err == nil || e.ErrorCode != errno.JwchNetworkErrorCode || e.Attempts != 3 {
+		t.Errorf("expected JwchNetworkError after 3 attempts, got %v", err)
+	}
+
+	// 未设置重试策略时不重试
+	flaky = &flakyTransport{path: "/xl.asp", failures: 1}
+	stu = srv.NewStudent(jwch.WithTransport(flaky))
+	if _, err = stu.GetSchoolCalendar(); errno.ConvertErr(err).ErrorCode != errno.JwchNetworkErrorCode || flaky.Calls() != 1 {
+		t.Errorf("expected a single failed attempt, got %v after %d calls", err, flaky.Calls())
+	}
+}
+
+// 5xx 响应重试次数用尽后返回 JwchNetworkError，并记录尝试次数
+func TestRetryExhaustedStatus(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	unavailable := &unavailableTransport{path: "/xl.asp"}
+	policy := fastRetryPolicy()
+	stu := srv.NewStudent(jwch.WithTransport(unavailable), jwch.WithRetryPolicy(policy))
+	_, err := stu.GetSchoolCalendar()
+	e := errno.ConvertErr(err)
+	if !errors.Is(err, errno.JwchNetworkError) || e.Attempts != policy.MaxAttempts || !strings.Contains(e.ErrorMsg, "503") {
+		t.Errorf("expected JwchNetworkError after %d attempts, got %v", policy.MaxAttempts, err)
+	}
+	if int(unavailable.calls.Load()) != policy.MaxAttempts {
+		t.Errorf("expected %d calls, got %d", policy.MaxAttempts, unavailable.calls.Load())
+	}
+
+	// 未设置重试策略时只尝试一次
+	stu = srv.NewStudent(jwch.WithTransport(&unavailableTransport{path: "/xl.asp"}))
+	if _, err = stu.GetSchoolCalendar(); !errors.Is(err, errno.JwchNetworkError) || errno.ConvertErr(err).Attempts != 0 {
+		t.Errorf("expected JwchNetworkError without attempts, got %v", err)
+	}
+}
+
+func TestRetrySkipsUnsafeRequests(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	// 登录时获取会话的 loginchk 虽然是 GET，但有副作用，不能重试
+	flaky := &flakyTransport{path: "/loginchk_xs.aspx", failures: 1}
+	stu := srv.NewStudent(jwch.WithTransport(flaky), jwch.WithRetryPolicy(fastRetryPolicy()))
+	if err := stu.Login(); err == nil {
+		t.Fatal("expected login to fail")
+	}
+	if flaky.Calls() != 1 {
+		t.Errorf("loginchk was retried %d times", flaky.Calls()-1)
+	}
+
+	// POST 提交不重试，但回发前获取 VIEWSTATE 的 GET 会重试
+	const classroom = "/kkgl/kbcx/kbcx_kjs.aspx"
+	posts := &flakyTransport{path: classroom, method: http.MethodPost, failures: 10}
+	gets := &flakyTransport{path: classroom, method: http.MethodGet, failures: 1, next: posts}
+	stu = srv.NewStudent(jwch.WithTransport(gets), jwch.WithRetryPolicy(fastRetryPolicy()))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := stu.GetEmptyRoom(jwch.EmptyRoomReq{Campus: "铜盘校区", Time: "2025-03-01", Start: "1", End: "2"})
+	if e := errno.ConvertErr(err); e.ErrorCode != errno.JwchNetworkErrorCode || e.Attempts > 1 {
+		t.Fatalf("expected JwchNetworkError without retries, got %v", err)
+	}
+	// 第一次 GET 失败后重试成功，随后的 POST 失败且不重试
+	if gets.Calls() != 2 || posts.Calls() != 1 {
+		t.Errorf("expected 2 GETs and 1 POST, got %d GETs and %d POSTs", gets.Calls(), posts.Calls())
+	}
+}
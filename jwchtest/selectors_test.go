@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/jwchtest"
+	"github.com/west2-online/jwch/selectors"
+)
+
+func TestSelectorsParse(t *testing.T) {
+	// 默认值序列化后可以原样加载
+	data, err := json.Marshal(selectors.Default())
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := selectors.Parse(data)
+	if err != nil {
+		t.Fatalf("default selectors rejected: %v", err)
+	}
+	if set.XPath(selectors.MarksTable) != selectors.Default().XPath(selectors.MarksTable) {
+		t.Errorf("unexpected marks table selector %q", set.XPath(selectors.MarksTable))
+	}
+
+	// 只覆盖文档中出现的部分
+	set, err = selectors.Parse([]byte(`{"xpath": {"user.name": "//*[@id='LB_name']"}, "columns": {"marks": {"score": "成绩"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.XPath(selectors.UserName) != `//*[@id='LB_name']` || set.Columns(selectors.Marks)["score"] != "成绩" {
+		t.Errorf("override not applied: %q %v", set.XPath(selectors.UserName), set.Columns(selectors.Marks))
+	}
+	if set.Columns(selectors.Marks)["name"] != "课程名称" || set.XPath(selectors.UserSex) != selectors.Default().XPath(selectors.UserSex) {
+		t.Errorf("defaults not kept")
+	}
+
+	invalid := map[string]string{
+		"syntax":           `{"xpath": `,
+		"unknown selector": `{"xpath": {"marks.tabel": "//table"}}`,
+		"invalid xpath":    `{"xpath": {"marks.table": "//*[@id="}}`,
+		"empty xpath":      `{"xpath": {"marks.table": " "}}`,
+		"unknown table":    `{"columns": {"mark": {"score": "成绩"}}}`,
+		"unknown column":   `{"columns": {"marks": {"scores": "成绩"}}}`,
+		"empty header":     `{"columns": {"marks": {"score": ""}}}`,
+		"duplicate header": `{"columns": {"marks": {"score": "绩点"}}}`,
+	}
+	for name, doc := range invalid {
+		if _, err := selectors.Parse([]byte(doc)); !errors.Is(err, errno.ParamError) {
+			t.Errorf("%s: expected ParamError, got %v", name, err)
+		}
+	}
+}
+
+func TestSelectorsRegistry(t *testing.T) {
+	srv := jwchtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	registry := selectors.NewRegistry()
+	stu := srv.NewStudent(jwch.WithSelectors(registry))
+	if err := stu.Login(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 教务处把“得分”改成了“成绩”，默认选择器无法解析
+	srv.SetFixture("marks", dataList(
+		[]string{"修读类别", "开课学期", "课程名称", "计划学分", "成绩", "绩点", "获得学分", "选课类型", "考试类别", "任课教师", "上课时间地点", "考试时间地点"},
+		[]string{"主修", "202401", "线性代数", "<span>3.0</span>", "<b>88</b>", "3.7", "3.0", "必修", "考试", "李老师", "", ""},
+	))
+	if _, err := stu.GetMarks(); !errors.Is(err, errno.HTMLParseError) {
+		t.Fatalf("expected HTMLParseError, got %v", err)
+	}
+
+	// 运行时加载新的列映射后立即生效
+	if err := registry.Load([]byte(`{"xpath": {"marks.score": "b"}, "columns": {"marks": {"score": "成绩"}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	marks, err := stu.GetMarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(marks) != 1 || marks[0].Score != "88" || marks[0].GPA != "3.7" {
+		t.Errorf("unexpected marks: %+v", marks)
+	}
+
+	// 校验失败时保持原来的选择器
+	if err := registry.Load([]byte(`{"xpath": {"marks.table": "//*["}}`)); !errors.Is(err, errno.ParamError) {
+		t.Fatalf("expected ParamError, got %v", err)
+	}
+	if _, err := stu.GetMarks(); err != nil {
+		t.Errorf("registry changed after failed load: %v", err)
+	}
+
+	registry.Reset()
+	if _, err := stu.GetMarks(); !errors.Is(err, errno.HTMLParseError) {
+		t.Errorf("expected HTMLParseError after reset, got %v", err)
+	}
+}
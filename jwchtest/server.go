@@ -0,0 +1,597 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package jwchtest 提供一个基于 httptest 的教务处模拟服务器，
+// 页面内容来自 testdata 中录制的 HTML，用于在没有网络和真实账号的情况下测试 jwch 的全部接口
+package jwchtest
+
+import (
+	"bytes"
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/constants"
+	"github.com/west2-online/jwch/utils"
+)
+
+//go:embed testdata/*.html
+var fixtureFS embed.FS
+
+// 模拟服务器默认接受的账号信息
+const (
+	DefaultStudentID = "102300101"
+	DefaultPassword  = "jwch-password"
+	DefaultCaptcha   = "8a3k"
+)
+
+const (
+	sessionCookie = "ASP.NET_SessionId"
+	// 会话失效时教务处会重定向到这个地址
+	errorRedirect = "https://jwcjwxt2.fzu.edu.cn:82/error.asp?id=300"
+	// 需要评议时教务处会重定向到评议页面
+	evaluationPath = "/student/jscp/TeaList.aspx"
+	// 通知公告的总页数
+	noticeTotalPages = 3
+)
+
+// Server 教务处模拟服务器
+type Server struct {
+	*httptest.Server
+
+	StudentID string // 可以登录的学号
+	Password  string // 对应的密码
+	Captcha   string // 验证码识别服务返回、logincheck 接受的验证码
+
+	mu             sync.Mutex
+	fixtures       map[string][]byte
+	identifier     string         // 当前会话的 id
+	session        string         // 当前会话的 ASP.NET_SessionId
+	ssoToken       string         // logincheck 签发给 SSO 的 token
+	logins         int            // 成功登录的次数
+	captchaRejects int            // 接下来需要拒绝的验证码次数
+	evaluation     bool           // 是否要求先进行评议
+	arrears        bool           // 是否欠缴学费
+	locked         bool           // 账号是否被锁定
+	maintenance    bool           // 登录验证是否返回系统维护
+	loginRedirect  string         // 不为空时登录验证通过后重定向到这个地址
+	hits           map[string]int // 每个路径被请求的次数
+	expireAfter    map[string]int // 路径被请求到这个次数后会话失效
+}
+
+// NewServer 启动一个模拟服务器，使用完毕后需要调用 Close
+func NewServer() *Server {
+	s := &Server{
+		StudentID:   DefaultStudentID,
+		Password:    DefaultPassword,
+		Captcha:     DefaultCaptcha,
+		fixtures:    make(map[string][]byte),
+		hits:        make(map[string]int),
+		expireAfter: make(map[string]int),
+	}
+
+	entries, err := fixtureFS.ReadDir("testdata")
+	if err != nil {
+		panic(err)
+	}
+	for _, entry := range entries {
+		data, err := fixtureFS.ReadFile("testdata/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		s.fixtures[strings.TrimSuffix(entry.Name(), ".html")] = data
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// NewStudent 返回一个指向模拟服务器、携带默认账号的 Student，尚未登录
+// 不会读取环境变量中的代理配置，opts 可以追加其他配置
+func (s *Server) NewStudent(opts ...jwch.Option) *jwch.Student {
+	opts = append([]jwch.Option{jwch.WithConfig(nil)}, opts...)
+	return jwch.NewStudent(opts...).WithBaseURL(s.URL).WithUser(s.StudentID, s.Password)
+}
+
+// NewLoggedInStudent 返回一个已经在模拟服务器上登录的 Student
+func (s *Server) NewLoggedInStudent() (*jwch.Student, error) {
+	stu := s.NewStudent()
+	if err := stu.Login(); err != nil {
+		return nil, err
+	}
+	return stu, nil
+}
+
+// SetFixture 替换某个页面的 HTML，name 为 testdata 中不带扩展名的文件名
+func (s *Server) SetFixture(name string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures[name] = data
+}
+
+// ExpireSession 使当前会话失效，模拟 ASP.NET 会话过期
+func (s *Server) ExpireSession() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = ""
+	s.identifier = ""
+}
+
+// ExpireSessionAfter 使会话在 path 再被请求 n 次之后失效，用于模拟多步回发过程中会话过期
+func (s *Server) ExpireSessionAfter(path string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireAfter[path] = s.hits[path] + n
+}
+
+// RequireEvaluation 设置是否需要先完成教师评议
+func (s *Server) RequireEvaluation(required bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evaluation = required
+}
+
+// SetArrears 设置是否欠缴学费，欠费时成绩页面只有一个 alert
+func (s *Server) SetArrears(arrears bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.arrears = arrears
+}
+
+// RejectCaptcha 让接下来的 n 次登录验证都返回验证码错误
+func (s *Server) RejectCaptcha(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captchaRejects = n
+}
+
+// LockAccount 设置账号是否被锁定
+func (s *Server) LockAccount(locked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locked = locked
+}
+
+// SetMaintenance 设置登录验证是否返回系统维护的提示
+func (s *Server) SetMaintenance(maintenance bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maintenance = maintenance
+}
+
+// SetLoginRedirect 让登录验证通过后重定向到 location，为空时恢复正常的 SSO 跳转
+func (s *Server) SetLoginRedirect(location string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loginRedirect = location
+}
+
+// Identifier 返回当前会话的 id
+func (s *Server) Identifier() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.identifier
+}
+
+// Logins 返回成功登录的次数
+func (s *Server) Logins() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins
+}
+
+// Hits 返回某个路径被请求的次数
+func (s *Server) Hits(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits[path]
+}
+
+// ViewState 返回当前会话下某个页面签发的 __VIEWSTATE，提交时必须原样带回
+// 与真实的教务处一样，VIEWSTATE 与会话绑定，重新登录后旧的 VIEWSTATE 会失效
+func (s *Server) ViewState(path string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return viewState(path, s.session)
+}
+
+// EventValidation 返回当前会话下某个页面签发的 __EVENTVALIDATION
+func (s *Server) EventValidation(path string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return eventValidation(path, s.session)
+}
+
+func viewState(path, session string) string {
+	return base64.StdEncoding.EncodeToString([]byte("viewstate:" + path + ":" + session))
+}
+
+func eventValidation(path, session string) string {
+	return base64.StdEncoding.EncodeToString([]byte("eventvalidation:" + path + ":" + session))
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.hits[r.URL.Path]++
+	if n, ok := s.expireAfter[r.URL.Path]; ok && s.hits[r.URL.Path] > n {
+		delete(s.expireAfter, r.URL.Path)
+		s.session = ""
+		s.identifier = ""
+	}
+	s.mu.Unlock()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.URL.Path {
+	// 登录流程
+	case "/plus/verifycode.asp":
+		s.serveVerifyCode(w)
+	case "/api/login/validateCode":
+		writeJSON(w, map[string]string{"message": s.Captcha})
+	case "/logincheck.asp":
+		s.serveLoginCheck(w, r)
+	case "/Sfrz/SSOLogin":
+		s.serveSSOLogin(w, r)
+	case "/loginchk_xs.aspx":
+		s.serveLoginChk(w, r)
+
+	// 无需登录的页面
+	case "/xl.asp":
+		if r.Method == http.MethodPost {
+			s.writeGB(w, r, "term_events", nil)
+			return
+		}
+		s.writeGB(w, r, "school_calendar", nil)
+	case "/week.asp":
+		s.writeGB(w, r, "locate_date", nil)
+	case "/jxtz.htm":
+		s.serveNoticeList(w, r, 1)
+	case "/content.jsp":
+		s.writeHTML(w, r, "notice_detail", nil)
+	case "/error.asp":
+		writeString(w, "<html><body>您还没有登录或者登录已超时，请重新登录！</body></html>")
+	case evaluationPath:
+		s.writeHTML(w, r, "evaluation", nil)
+
+	default:
+		if strings.HasPrefix(r.URL.Path, "/jxtz/") {
+			page, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/jxtz/"), ".htm"))
+			if err != nil || page < 1 || page >= noticeTotalPages {
+				http.NotFound(w, r)
+				return
+			}
+			s.serveNoticeList(w, r, noticeTotalPages-page+1)
+			return
+		}
+		s.serveSessionPage(w, r)
+	}
+}
+
+// serveSessionPage 处理需要登录态的 :81 页面
+func (s *Server) serveSessionPage(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		if r.Method == http.MethodPost {
+			writeString(w, "<html><body>处理URL失败，请重新登录</body></html>")
+			return
+		}
+		w.Header().Set("Location", errorRedirect)
+		w.WriteHeader(http.StatusFound)
+		return
+	}
+
+	s.mu.Lock()
+	evaluation := s.evaluation
+	s.mu.Unlock()
+	if evaluation {
+		w.Header().Set("Location", evaluationPath+"?id="+r.URL.Query().Get("id"))
+		w.WriteHeader(http.StatusFound)
+		return
+	}
+
+	path := r.URL.Path
+	// ASP.NET 回发必须带上页面签发的 VIEWSTATE
+	state, validation := s.ViewState(path), s.EventValidation(path)
+	if path == pathOf(constants.ClassroomQueryURL) && r.PostForm.Get("ctl00$jslxdpl") != "" {
+		// 查询空教室时需要带上选择校区（教学楼）那次回发签发的 VIEWSTATE
+		state, validation = s.ViewState(path+"#"+r.PostForm.Get("ctl00$jxldpl")), s.EventValidation(path+"#"+r.PostForm.Get("ctl00$jxldpl"))
+	}
+	if r.Method == http.MethodPost && (r.PostForm.Get("__VIEWSTATE") != state ||
+		r.PostForm.Get("__EVENTVALIDATION") != validation) {
+		http.Error(w, "Validation of viewstate MAC failed.", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case path == pathOf(constants.UserInfoURL):
+		s.writeHTML(w, r, "student_info", nil)
+	case path == pathOf(constants.CourseURL):
+		if r.Method == http.MethodPost {
+			s.writeHTML(w, r, "courses", nil)
+			return
+		}
+		s.writeHTML(w, r, "course_terms", nil)
+	case path == pathOf(constants.MarksQueryURL):
+		s.mu.Lock()
+		arrears := s.arrears
+		s.mu.Unlock()
+		if arrears {
+			s.writeHTML(w, r, "marks_arrears", nil)
+			return
+		}
+		s.writeHTML(w, r, "marks", nil)
+	case path == pathOf(constants.CETQueryURL):
+		s.writeHTML(w, r, "cet", nil)
+	case path == pathOf(constants.JSQueryURL):
+		s.writeHTML(w, r, "js", nil)
+	case path == pathOf(constants.CreditQueryURL):
+		s.writeHTML(w, r, "credit", nil)
+	case path == pathOf(constants.GPAQueryURL):
+		s.writeHTML(w, r, "gpa", nil)
+	case path == pathOf(constants.ExamRoomQueryURL):
+		s.writeHTML(w, r, "exam_room", nil)
+	case path == pathOf(constants.LectureURL):
+		s.writeHTML(w, r, "lectures", nil)
+	case path == pathOf(constants.ClassroomQueryURL):
+		s.serveEmptyRoom(w, r)
+	case path == pathOf(constants.CultivatePlanURL):
+		majors := ""
+		if r.PostForm.Get("ctl00$xymcdpl") == "05" {
+			majors = `<option value="0501">计算机科学与技术</option><option value="0502">软件工程</option>`
+		}
+		s.writeHTML(w, r, "cultivate_plan", map[string]string{"MAJORS": majors})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session != "" && cookie.Value == s.session && r.URL.Query().Get("id") == s.identifier
+}
+
+func (s *Server) serveVerifyCode(w http.ResponseWriter) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 60, 20))); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: "ASPSESSIONIDQATRCQBS", Value: "VERIFYCODESESSION", Path: "/"})
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (s *Server) serveLoginCheck(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maintenance {
+		s.writeGBLocked(w, r, "login_failed", map[string]string{"MESSAGE": "系统维护中，请稍后再试！"})
+		return
+	}
+	if s.captchaRejects > 0 || r.PostForm.Get("Verifycode") != s.Captcha {
+		if s.captchaRejects > 0 {
+			s.captchaRejects--
+		}
+		s.writeGBLocked(w, r, "login_failed", map[string]string{"MESSAGE": "验证码验证失败！"})
+		return
+	}
+	if r.PostForm.Get("muser") != s.StudentID {
+		s.writeGBLocked(w, r, "login_failed", map[string]string{"MESSAGE": "该用户不存在！"})
+		return
+	}
+	if s.locked {
+		s.writeGBLocked(w, r, "login_failed", map[string]string{"MESSAGE": "密码错误次数过多，账号已被锁定！"})
+		return
+	}
+	if r.PostForm.Get("passwd") != utils.Md5Hash(s.Password, 16) {
+		s.writeGBLocked(w, r, "login_failed", map[string]string{"MESSAGE": "用户名或密码错误！"})
+		return
+	}
+	if s.loginRedirect != "" {
+		w.Header().Set("Location", s.loginRedirect)
+		w.WriteHeader(http.StatusFound)
+		return
+	}
+
+	s.ssoToken = fmt.Sprintf("token%04d", s.logins+1)
+	w.Header().Set("Location", fmt.Sprintf(
+		"https://jwcjwxt2.fzu.edu.cn/Sfrz/login?token=%s&id=%s&num=%d&ssourl=https://jwcjwxt2.fzu.edu.cn&hosturl=https://jwcjwxt2.fzu.edu.cn:81&ssologin=",
+		s.ssoToken, s.StudentID, s.logins+1))
+	w.WriteHeader(http.StatusFound)
+}
+
+func (s *Server) serveSSOLogin(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	valid := s.ssoToken != "" && r.PostForm.Get("token") == s.ssoToken
+	s.mu.Unlock()
+
+	if !valid {
+		writeJSON(w, map[string]any{"code": 400, "info": "账号不存在"})
+		return
+	}
+	writeJSON(w, map[string]any{"code": 200, "info": "登录成功"})
+}
+
+func (s *Server) serveLoginChk(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ssoToken == "" || r.URL.Query().Get("id") != s.StudentID {
+		writeString(w, "<html><body>处理URL失败，请重新登录</body></html>")
+		return
+	}
+
+	s.ssoToken = ""
+	s.logins++
+	s.identifier = fmt.Sprintf("20250901%012d", s.logins)
+	s.session = fmt.Sprintf("fakesession%08d", s.logins)
+
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: s.session, Path: "/", HttpOnly: true})
+	w.Header().Set("Location", "/default.aspx?id="+s.identifier+"&login=1")
+	w.WriteHeader(http.StatusFound)
+}
+
+func (s *Server) serveEmptyRoom(w http.ResponseWriter, r *http.Request) {
+	vars := map[string]string{"BUILDINGS": "", "ROOM_TYPES": "", "ROOMS": ""}
+	for _, building := range constants.BuildingArray {
+		vars["BUILDINGS"] += "<option>" + building + "</option>"
+	}
+
+	if r.Method == http.MethodPost {
+		building := r.PostForm.Get("ctl00$jxldpl")
+		roomType := r.PostForm.Get("ctl00$jslxdpl")
+		if roomType == "" {
+			// 第一次提交：返回该校区（教学楼）的教室类型，页面状态随之改变
+			vars["VIEWSTATE"] = s.ViewState(r.URL.Path + "#" + building)
+			vars["EVENTVALIDATION"] = s.EventValidation(r.URL.Path + "#" + building)
+			for _, t := range roomTypes(building) {
+				vars["ROOM_TYPES"] += "<option>" + t + "</option>"
+			}
+		} else {
+			// 第二次提交：返回该类型下的空教室
+			for _, room := range EmptyRooms(r.PostForm.Get("ctl00$xqdpl"), building, roomType) {
+				vars["ROOMS"] += "<option>" + room + "</option>"
+			}
+		}
+	}
+	s.writeHTML(w, r, "empty_room", vars)
+}
+
+func roomTypes(building string) []string {
+	if building != "" {
+		return []string{"多媒体教室"}
+	}
+	return []string{"多媒体教室", "普通教室"}
+}
+
+// EmptyRooms 返回模拟服务器中某个校区、教学楼、教室类型下的空教室
+func EmptyRooms(campus, building, roomType string) []string {
+	if building != "" {
+		short := strings.TrimPrefix(building, "公共教学楼")
+		return []string{short + "-101", short + "-102"}
+	}
+	switch roomType {
+	case "多媒体教室":
+		return []string{campus + "A101", campus + "A102"}
+	case "普通教室":
+		return []string{campus + "B201"}
+	}
+	return nil
+}
+
+func (s *Server) serveNoticeList(w http.ResponseWriter, r *http.Request, page int) {
+	notices := ""
+	for i := 0; i < 3; i++ {
+		newsID := 13800 - page*10 - i
+		href := fmt.Sprintf("info/1036/%d.htm", newsID)
+		if i == 2 {
+			href = fmt.Sprintf("../content.jsp?urltype=news.NewsContentUrl&amp;wbtreeid=1035&amp;wbnewsid=%d", newsID)
+		}
+		notices += fmt.Sprintf(`<li><span class="doclist_time">2025-06-%02d</span><a href="%s" target="_blank" title="第%d页通知%d">第%d页通知%d</a></li>`,
+			20-page-i, href, page, i+1, page, i+1)
+	}
+	s.writeHTML(w, r, "notice_list", map[string]string{
+		"NOTICES":     notices,
+		"PAGE":        strconv.Itoa(page),
+		"TOTAL_PAGES": strconv.Itoa(noticeTotalPages),
+	})
+}
+
+// render 替换页面中的 {{KEY}} 占位符
+func (s *Server) render(r *http.Request, name string, vars map[string]string, encode func(string) string) []byte {
+	data := s.fixtures[name]
+	replacements := map[string]string{
+		"ID":              s.identifier,
+		"STUDENT_ID":      s.StudentID,
+		"VIEWSTATE":       viewState(r.URL.Path, s.session),
+		"EVENTVALIDATION": eventValidation(r.URL.Path, s.session),
+	}
+	for k, v := range vars {
+		replacements[k] = v
+	}
+	for k, v := range replacements {
+		data = bytes.ReplaceAll(data, []byte("{{"+k+"}}"), []byte(encode(v)))
+	}
+	return data
+}
+
+func (s *Server) writeHTML(w http.ResponseWriter, r *http.Request, name string, vars map[string]string) {
+	s.mu.Lock()
+	data := s.render(r, name, vars, func(v string) string { return v })
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(data)
+}
+
+// writeGB 输出 GB2312 编码的页面（:82 端口上的 asp 页面）
+func (s *Server) writeGB(w http.ResponseWriter, r *http.Request, name string, vars map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writeGBLocked(w, r, name, vars)
+}
+
+func (s *Server) writeGBLocked(w http.ResponseWriter, r *http.Request, name string, vars map[string]string) {
+	data := s.render(r, name, vars, func(v string) string {
+		encoded, err := simplifiedchinese.GB18030.NewEncoder().String(v)
+		if err != nil {
+			return v
+		}
+		return encoded
+	})
+	// asp 页面不在 Content-Type 中声明编码，只能依靠 meta 标签
+	w.Header().Set("Content-Type", "text/html")
+	_, _ = w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeString(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(body))
+}
+
+// pathOf 返回 URL 常量中的路径部分
+func pathOf(rawURL string) string {
+	rest := rawURL[strings.Index(rawURL, "://")+3:]
+	if i := strings.Index(rest, "/"); i >= 0 {
+		return rest[i:]
+	}
+	return "/"
+}
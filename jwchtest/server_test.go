@@ -0,0 +1,510 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/constants"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/jwchtest"
+)
+
+func newLoggedIn(t *testing.T) (*jwchtest.Server, *jwch.Student) {
+	t.Helper()
+	srv := jwchtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	stu, err := srv.NewLoggedInStudent()
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	return srv, stu
+}
+
+func TestLogin(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+
+	if stu.Identifier == "" || stu.Identifier != srv.Identifier() {
+		t.Fatalf("identifier mismatch: got %q, server %q", stu.Identifier, srv.Identifier())
+	}
+	if err := stu.CheckSession(); err != nil {
+		t.Fatalf("CheckSession: %v", err)
+	}
+
+	id, cookies, err := stu.GetIdentifierAndCookies()
+	if err != nil {
+		t.Fatalf("GetIdentifierAndCookies: %v", err)
+	}
+	if id != srv.Identifier() || len(cookies) == 0 {
+		t.Errorf("unexpected login data: %q %v", id, cookies)
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	stu := srv.NewStudent().WithUser(srv.StudentID, "wrong")
+	err := stu.Login()
+	if !errors.As(err, new(errno.ErrNo)) {
+		t.Fatalf("expected ErrNo, got %v", err)
+	}
+	if srv.Logins() != 0 {
+		t.Errorf("unexpected successful login")
+	}
+}
+
+func TestLoginFailures(t *testing.T) {
+	cases := []struct {
+		name  string
+		setup func(srv *jwchtest.Server, stu *jwch.Student)
+		code  int64
+	}{
+		{"wrong password", func(srv *jwchtest.Server, stu *jwch.Student) { stu.WithUser(srv.StudentID, "wrong") }, errno.WrongPasswordErrorCode},
+		{"account not found", func(srv *jwchtest.Server, stu *jwch.Student) { stu.WithUser("000000000", "wrong") }, errno.AccountNotFoundErrorCode},
+		{"account locked", func(srv *jwchtest.Server, stu *jwch.Student) { srv.LockAccount(true) }, errno.AccountLockedErrorCode},
+		{"maintenance", func(srv *jwchtest.Server, stu *jwch.Student) { srv.SetMaintenance(true) }, errno.MaintenanceErrorCode},
+		{"wrong captcha", func(srv *jwchtest.Server, stu *jwch.Student) { srv.RejectCaptcha(10) }, errno.WrongCaptchaErrorCode},
+		{"redirect without token", func(srv *jwchtest.Server, stu *jwch.Student) {
+			srv.SetLoginRedirect("https://jwcjwxt2.fzu.edu.cn:82/error.asp?id=300")
+		}, errno.UnexpectedRedirectErrorCode},
+		{"redirect without num", func(srv *jwchtest.Server, stu *jwch.Student) {
+			srv.SetLoginRedirect("https://jwcjwxt2.fzu.edu.cn/Sfrz/login?token=abc&id=" + srv.StudentID)
+		}, errno.UnexpectedRedirectErrorCode},
+		{"redirect to home", func(srv *jwchtest.Server, stu *jwch.Student) { srv.SetLoginRedirect("/") }, errno.UnexpectedRedirectErrorCode},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := jwchtest.NewServer()
+			defer srv.Close()
+
+			stu := srv.NewStudent()
+			c.setup(srv, stu)
+			err := stu.Login()
+			if got := errno.ConvertErr(err).ErrorCode; err == nil || got != c.code {
+				t.Errorf("expected code %d, got %v", c.code, err)
+			}
+			if strings.Contains(errno.ConvertErr(err).ErrorMsg, "token=") {
+				t.Errorf("error leaks redirect parameters: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoginCaptchaRetry(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	var solved int
+	stu := srv.NewStudent().WithCaptchaSolver(jwch.NewManualCaptchaSolver(func(image []byte) (string, error) {
+		solved++
+		return srv.Captcha, nil
+	}))
+
+	srv.RejectCaptcha(2)
+	if err := stu.Login(); err != nil {
+		t.Fatalf("login with captcha retry: %v", err)
+	}
+	if solved != 3 {
+		t.Errorf("expected 3 captcha attempts, got %d", solved)
+	}
+
+	srv.RejectCaptcha(5)
+	if err := stu.WithCaptchaAttempts(2).Login(); err == nil {
+		t.Errorf("expected login failure after exhausting captcha attempts")
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+	srv.ExpireSession()
+
+	if err := stu.CheckSession(); !errors.Is(err, errno.CookieError) {
+		t.Errorf("CheckSession: expected CookieError, got %v", err)
+	}
+	if _, err := stu.GetMarks(); !errors.Is(err, errno.CookieError) {
+		t.Errorf("GetMarks: expected CookieError, got %v", err)
+	}
+}
+
+func TestEvaluationRequired(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+	srv.RequireEvaluation(true)
+
+	if _, err := stu.GetMarks(); !errors.Is(err, errno.EvaluationNotFoundError) {
+		t.Errorf("expected EvaluationNotFoundError, got %v", err)
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	info, err := stu.GetInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != "张三" || info.College != "计算机与大数据学院" || info.Major != "计算机科学与技术" || info.Grade != "2023" {
+		t.Errorf("unexpected info: %+v", info)
+	}
+}
+
+func TestGetSemesterCourses(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	terms, err := stu.GetTerms()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(terms.Terms, []string{"202501", "202402", "202401", "202302"}) {
+		t.Errorf("unexpected terms: %v", terms.Terms)
+	}
+
+	courses, err := stu.GetSemesterCourses(terms.Terms[0], terms.ViewState, terms.EventValidation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(courses) != 3 {
+		t.Fatalf("expected 3 courses, got %d", len(courses))
+	}
+
+	ds := courses[0]
+	if ds.Name != "数据结构" || ds.Credits != "3.0" || ds.Teacher != "王老师" || ds.ElectiveType != "必修" || ds.ExamType != "考试" {
+		t.Errorf("unexpected course: %+v", ds)
+	}
+	if !strings.HasSuffix(ds.Syllabus, "/pyfa/kcdg/kcdg_view.aspx?kcdm=10010") {
+		t.Errorf("unexpected syllabus: %s", ds.Syllabus)
+	}
+	expectedRules := []jwch.CourseScheduleRule{
+		{Location: "旗山西1-206", StartClass: 3, EndClass: 4, StartWeek: 1, EndWeek: 16, Weekday: 1, Single: true, Double: true},
+		{Location: "旗山西1-206", StartClass: 1, EndClass: 2, StartWeek: 1, EndWeek: 16, Weekday: 3, Single: true, Double: false},
+	}
+	if !reflect.DeepEqual(ds.ScheduleRules, expectedRules) {
+		t.Errorf("unexpected schedule rules: %+v", ds.ScheduleRules)
+	}
+	expectedAdjust := []jwch.CourseAdjustRule{
+		{OldWeek: 6, OldWeekday: 1, OldStartClass: 3, OldEndClass: 4, NewWeek: 9, NewWeekday: 5, NewStartClass: 7, NewEndClass: 8, NewLocation: "旗山东3-101"},
+	}
+	if !reflect.DeepEqual(ds.AdjustRules, expectedAdjust) {
+		t.Errorf("unexpected adjust rules: %+v", ds.AdjustRules)
+	}
+	if ds.RawExamTime != "2025年01月09日 14:00-16:00 旗山东3-101" {
+		t.Errorf("unexpected exam time: %q", ds.RawExamTime)
+	}
+
+	military := courses[1]
+	if len(military.FullWeekScheduleRules) != 1 || len(military.ScheduleRules) != 7 {
+		t.Errorf("unexpected full week rules: %+v", military)
+	}
+
+	english := courses[2]
+	if len(english.ScheduleRules) != 1 || english.ScheduleRules[0].Single || !english.ScheduleRules[0].Double {
+		t.Errorf("unexpected double week rule: %+v", english.ScheduleRules)
+	}
+}
+
+func TestGetSemesterCoursesBadViewState(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	if _, err := stu.GetSemesterCourses("202501", "stale", "stale"); err == nil {
+		t.Errorf("expected error for stale viewstate")
+	}
+}
+
+func TestGetMarks(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+
+	marks, err := stu.GetMarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(marks) != 4 {
+		t.Fatalf("expected 4 marks, got %d", len(marks))
+	}
+	if marks[0].Name != "高等数学A（上）" || marks[0].Score != "92" || marks[0].Credits != "5.0" || marks[0].GPA != "4.0" {
+		t.Errorf("unexpected mark: %+v", marks[0])
+	}
+	if marks[0].ExamTime != "2025年01月10日 08:30-10:30 旗山东3-201" {
+		t.Errorf("unexpected exam time: %q", marks[0].ExamTime)
+	}
+
+	srv.SetArrears(true)
+	_, err = stu.GetMarks()
+	if !errors.Is(err, errno.TuitionArrearsError) || !strings.Contains(err.Error(), "学费未缴清") {
+		t.Errorf("expected arrears error, got %v", err)
+	}
+}
+
+func TestGetUnifiedExam(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	cet, err := stu.GetCET()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cet) != 2 || cet[0].Score != "520" || cet[1].Term != "2024年06月" {
+		t.Errorf("unexpected cet: %+v", cet)
+	}
+
+	js, err := stu.GetJS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(js) != 1 || js[0].Score != "合格" {
+		t.Errorf("unexpected js: %+v", js)
+	}
+}
+
+func TestGetCredit(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	credits, err := stu.GetCredit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(credits) != 5 || credits[0].Type != "学科基础课" || credits[0].Total != "40.0" || credits[0].Gain != "36.0" {
+		t.Errorf("unexpected credits: %+v", credits)
+	}
+
+	major, minor, err := stu.GetCreditV2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(major) != 4 || len(minor) != 1 || minor[0].Gain != "8.0" {
+		t.Errorf("unexpected credits v2: %+v %+v", major, minor)
+	}
+}
+
+func TestGetGPA(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	gpa, err := stu.GetGPA()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gpa.Time != "绩点计算时间：2025-01-20 08:00:00" || len(gpa.Data) != 6 {
+		t.Fatalf("unexpected gpa: %+v", gpa)
+	}
+	if gpa.Data[1] != (jwch.GPAData{Type: "平均学分绩点", Value: "3.52"}) {
+		t.Errorf("unexpected gpa data: %+v", gpa.Data[1])
+	}
+}
+
+func TestGetExamRoom(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	rooms, err := stu.GetExamRoom(jwch.ExamRoomReq{Term: "202401"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms) != 2 {
+		t.Fatalf("expected 2 exam rooms, got %d", len(rooms))
+	}
+	if rooms[0].Date != "2024年11月17日" || rooms[0].Time != "12:30-17:30" || rooms[0].Location != "旗山数计3-404" {
+		t.Errorf("unexpected exam room: %+v", rooms[0])
+	}
+	if rooms[1].Location != "暂无考场数据" {
+		t.Errorf("unexpected empty exam room: %+v", rooms[1])
+	}
+}
+
+func TestGetEmptyRoom(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	req := jwch.EmptyRoomReq{Campus: "铜盘校区", Time: "2025-03-01", Start: "1", End: "2"}
+	rooms, err := stu.GetEmptyRoom(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append(jwchtest.EmptyRooms(req.Campus, "", "多媒体教室"), jwchtest.EmptyRooms(req.Campus, "", "普通教室")...)
+	if !reflect.DeepEqual(rooms, expected) {
+		t.Errorf("unexpected rooms: %v", rooms)
+	}
+
+	req.Campus = "旗山校区"
+	rooms, err = stu.GetQiShanEmptyRoom(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms) != 2*len(constants.BuildingArray) || rooms[0] != "东1-101" {
+		t.Errorf("unexpected qishan rooms: %v", rooms)
+	}
+}
+
+func TestGetEmptyRoomCanceled(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := stu.GetQiShanEmptyRoomCtx(ctx, jwch.EmptyRoomReq{Campus: "旗山校区", Time: "2025-03-01", Start: "1", End: "2"})
+	if err == nil || errno.ConvertErr(err).ErrorCode != errno.ContextCanceledErrorCode {
+		t.Errorf("expected ContextCanceledError, got %v", err)
+	}
+}
+
+func TestGetSchoolCalendar(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+	stu := srv.NewStudent()
+
+	calendar, err := stu.GetSchoolCalendar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calendar.CurrentTerm != "202501" || len(calendar.Terms) != 3 {
+		t.Fatalf("unexpected calendar: %+v", calendar)
+	}
+	if calendar.Terms[1] != (jwch.CalTerm{TermId: "2024022025022420250704", SchoolYear: "2024", Term: "202402", StartDate: "2025-02-24", EndDate: "2025-07-04"}) {
+		t.Errorf("unexpected term: %+v", calendar.Terms[1])
+	}
+
+	events, err := stu.GetTermEvents(calendar.Terms[0].TermId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []jwch.CalTermEvent{
+		{Name: "新生入学教育", StartDate: "2025-09-01", EndDate: "2025-09-05"},
+		{Name: "学生注册", StartDate: "2025-09-07", EndDate: "2025-09-07"},
+		{Name: "国庆节放假", StartDate: "2025-10-01", EndDate: "2025-10-08"},
+	}
+	if !reflect.DeepEqual(events.Events, expected) {
+		t.Errorf("unexpected events: %+v", events.Events)
+	}
+}
+
+func TestGetLocateDate(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	date, err := srv.NewStudent().GetLocateDate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *date != (jwch.LocateDate{Week: "5", Year: "2025", Term: "01"}) {
+		t.Errorf("unexpected locate date: %+v", date)
+	}
+}
+
+func TestGetNotice(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+	stu := srv.NewStudent()
+
+	list, total, err := stu.GetNoticeInfo(&jwch.NoticeInfoReq{PageNum: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 || len(list) != 3 {
+		t.Fatalf("unexpected notice list: %d %+v", total, list)
+	}
+	if list[0].WbTreeId != "1036" || list[0].WbNewsId != "13790" || list[2].WbTreeId != "1035" {
+		t.Errorf("unexpected notice ids: %+v %+v", list[0], list[2])
+	}
+
+	list, _, err = stu.GetNoticeInfo(&jwch.NoticeInfoReq{PageNum: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list[0].Title != "第2页通知1" {
+		t.Errorf("unexpected second page: %+v", list[0])
+	}
+
+	if _, _, err = stu.GetNoticeInfo(&jwch.NoticeInfoReq{PageNum: 4}); err == nil {
+		t.Errorf("expected error for page out of range")
+	}
+
+	detail, err := stu.GetNoticeDetail(&jwch.NoticeDetailReq{WbTreeId: list[0].WbTreeId, WbNewsId: list[0].WbNewsId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Title != "关于2025年春季学期期末考试安排的通知" || detail.Date != "2025-06-01" || !strings.Contains(detail.Content, "第18-19周") {
+		t.Errorf("unexpected notice detail: %+v", detail)
+	}
+}
+
+func TestGetLectures(t *testing.T) {
+	_, stu := newLoggedIn(t)
+
+	lectures, err := stu.GetLectures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lectures) != 2 {
+		t.Fatalf("expected 2 lectures, got %d", len(lectures))
+	}
+	if lectures[0].IssueNumber != 12 || lectures[0].Title != "中国传统文化漫谈" || lectures[0].Timestamp != 1728730800000 {
+		t.Errorf("unexpected lecture: %+v", lectures[0])
+	}
+}
+
+func TestGetCultivatePlan(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+
+	url, err := stu.GetCultivatePlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := srv.URL + "/pyfa/pyjh/pyfa_bzy.aspx?nj=2023&xyh=05&zyh=0501&zylb=本专业&id=" + srv.Identifier()
+	if url != expected {
+		t.Errorf("unexpected cultivate plan url: %s", url)
+	}
+}
+
+func TestEndpoints(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	endpoints := srv.NewStudent().Endpoints()
+	v := reflect.ValueOf(endpoints)
+	for i := 0; i < v.NumField(); i++ {
+		if !strings.HasPrefix(v.Field(i).String(), srv.URL) {
+			t.Errorf("%s not rewritten: %s", v.Type().Field(i).Name, v.Field(i).String())
+		}
+	}
+	if endpoints.AutoCaptchaVerifyURL != srv.URL+"/api/login/validateCode?validateCode" {
+		t.Errorf("query lost: %s", endpoints.AutoCaptchaVerifyURL)
+	}
+
+	if _, err := jwch.DefaultEndpoints().WithBaseURL("not a url"); err == nil {
+		t.Errorf("expected error for invalid base url")
+	}
+
+	moved := jwch.DefaultEndpoints().ReplacePrefix("https://jwcjwxt2.fzu.edu.cn:82", "https://jwcjwxt2.fzu.edu.cn:8082")
+	if moved.SchoolCalendarURL != "https://jwcjwxt2.fzu.edu.cn:8082/xl.asp" || moved.CourseURL != constants.CourseURL {
+		t.Errorf("unexpected endpoints: %s %s", moved.SchoolCalendarURL, moved.CourseURL)
+	}
+}
+
+func TestNoticeURLUsesEndpoints(t *testing.T) {
+	srv := jwchtest.NewServer()
+	defer srv.Close()
+
+	list, _, err := srv.NewStudent().GetNoticeInfo(&jwch.NoticeInfoReq{PageNum: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, notice := range list {
+		if !strings.HasPrefix(notice.URL, srv.URL+"/") {
+			t.Errorf("notice url not rewritten: %s", notice.URL)
+		}
+	}
+}
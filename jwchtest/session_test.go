@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/jwchtest"
+)
+
+func TestSessionExportImport(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+
+	sess := stu.ExportSession()
+	if sess.Version != jwch.SessionVersion || sess.StudentID != srv.StudentID || sess.Identifier != srv.Identifier() {
+		t.Fatalf("unexpected session: %+v", sess)
+	}
+	if sess.LoginAt.IsZero() || time.Since(sess.LoginAt) > time.Minute {
+		t.Errorf("unexpected login time: %v", sess.LoginAt)
+	}
+	if len(sess.Cookies) == 0 || sess.Cookies[0].Domain != "127.0.0.1" {
+		t.Errorf("unexpected cookies: %+v", sess.Cookies)
+	}
+
+	data, err := sess.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := jwch.UnmarshalSession(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 另一个节点上的 Student 不需要登录即可查询
+	other := jwch.NewStudent(jwch.WithConfig(nil)).WithBaseURL(srv.URL)
+	if err = other.ImportSession(restored); err != nil {
+		t.Fatal(err)
+	}
+	if err = other.CheckSession(); err != nil {
+		t.Fatalf("CheckSession after import: %v", err)
+	}
+	if _, err = other.GetMarks(); err != nil {
+		t.Fatalf("GetMarks after import: %v", err)
+	}
+	if srv.Logins() != 1 {
+		t.Errorf("unexpected login")
+	}
+
+	if err = srv.NewStudent().WithUser("222200000", "").ImportSession(restored); !errors.Is(err, errno.AccountConflictError) {
+		t.Errorf("expected AccountConflictError, got %v", err)
+	}
+	if _, err = jwch.UnmarshalSession([]byte(`{"version":99}`)); err == nil {
+		t.Errorf("expected error for unsupported version")
+	}
+}
+
+func TestSessionStores(t *testing.T) {
+	stores := map[string]jwch.SessionStore{
+		"memory": jwch.NewMemorySessionStore(),
+		"file":   jwch.NewFileSessionStore(t.TempDir()),
+	}
+
+	sess := &jwch.Session{
+		Version:    jwch.SessionVersion,
+		StudentID:  jwchtest.DefaultStudentID,
+		Identifier: "20250901000000000001",
+		Cookies:    []jwch.SessionCookie{{Name: "ASP.NET_SessionId", Value: "abc", Path: "/"}},
+		LoginAt:    time.Date(2025, 9, 1, 8, 0, 0, 0, time.UTC),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := store.Load(ctx, sess.StudentID); !errors.Is(err, errno.SessionNotFoundError) {
+				t.Fatalf("expected SessionNotFoundError, got %v", err)
+			}
+			if err := store.Save(ctx, sess); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := store.Load(ctx, sess.StudentID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Identifier != sess.Identifier || len(loaded.Cookies) != 1 || !loaded.LoginAt.Equal(sess.LoginAt) {
+				t.Errorf("unexpected session: %+v", loaded)
+			}
+
+			if err = store.Delete(ctx, sess.StudentID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = store.Load(ctx, sess.StudentID); !errors.Is(err, errno.SessionNotFoundError) {
+				t.Errorf("expected SessionNotFoundError after delete, got %v", err)
+			}
+		})
+	}
+
+	if err := jwch.NewFileSessionStore(t.TempDir()).Save(context.Background(), &jwch.Session{StudentID: "../escape"}); err == nil {
+		t.Errorf("expected error for invalid student id")
+	}
+}
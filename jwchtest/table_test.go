@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/west2-online/jwch"
+	"github.com/west2-online/jwch/errno"
+)
+
+// dataList 生成教务处 DataList 表格页面，rows 为每行的单元格
+func dataList(rows ...[]string) []byte {
+	var b strings.Builder
+	b.WriteString(`<html><body><form id="form1">`)
+	b.WriteString(`<input type="hidden" id="__VIEWSTATE" value="{{VIEWSTATE}}" /><input type="hidden" id="__EVENTVALIDATION" value="{{EVENTVALIDATION}}" />`)
+	b.WriteString(`<table id="ContentPlaceHolder1_DataList_xxk"><tbody>`)
+	b.WriteString(`<tr><td colspan="12" align="center">标题</td></tr>`)
+	for i, row := range rows {
+		if i == 0 {
+			b.WriteString(`<tr style="height:30px; background:#efefef;">`)
+		} else {
+			b.WriteString(`<tr style="height:30px;" onmouseover="c=this.style.backgroundColor">`)
+		}
+		for _, cell := range row {
+			b.WriteString("<td>" + cell + "</td>")
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString(`<tr><td colspan="12"></td></tr></tbody></table></form></body></html>`)
+	return []byte(b.String())
+}
+
+func TestTableColumnsByHeader(t *testing.T) {
+	srv, stu := newLoggedIn(t)
+
+	// 列顺序变化时仍然按表头取值
+	srv.SetFixture("marks", dataList(
+		[]string{"课程名称", "得分", "修读类别", "开课学期", "计划学分", "绩点", "获得学分", "选课类型", "考试类别", "任课教师", "上课时间地点", "考试时间地点", "备注"},
+		[]string{"线性代数", `<font color="blue">88</font>`, "主修", "202401", "<span>3.0</span>", "3.7", "3.0", "必修", "考试", "李老师", "", "", ""},
+	))
+	marks, err := stu.GetMarks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(marks) != 1 || marks[0].Name != "线性代数" || marks[0].Score != "88" || marks[0].Credits != "3.0" || marks[0].Teacher != "李老师" {
+		t.Errorf("unexpected marks: %+v", marks)
+	}
+
+	srv.SetFixture("lectures", dataList(
+		[]string{"期号", "讲座类别", "讲座题目", "主讲人", "讲座时间", "讲座地点", "听取讲座情况"},
+		[]string{"7", "人文素质", "诗词赏析", "孙教授", "2024-10-12&nbsp;&nbsp;19：00", "图书馆", "已听取"},
+	))
+	lectures, err := stu.GetLectures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lectures) != 1 || lectures[0].IssueNumber != 7 || lectures[0].Category != "人文素质" || lectures[0].Timestamp == 0 {
+		t.Errorf("unexpected lectures: %+v", lectures[0])
+	}
+}
+
+func TestTableLayoutMismatch(t *testing.T) {
+	cases := []struct {
+		name    string
+		fixture string
+		page    []byte
+		message string
+	}{
+		{
+			name:    "missing header",
+			fixture: "marks",
+			page: dataList(
+				[]string{"修读类别", "开课学期", "课程名称", "计划学分", "成绩", "绩点", "获得学分", "选课类型", "考试类别", "任课教师", "上课时间地点", "考试时间地点"},
+			),
+			message: "marks table: header 得分 not found",
+		},
+		{
+			name:    "short row",
+			fixture: "lectures",
+			page: dataList(
+				[]string{"讲座类别", "期号", "讲座题目", "主讲人", "讲座时间", "讲座地点", "听取讲座情况"},
+				[]string{"人文素质", "12", "中国传统文化漫谈"},
+			),
+			message: "lectures table: row 1 has 3 cells, header has 7",
+		},
+		{
+			name:    "no table",
+			fixture: "lectures",
+			page:    []byte(`<html><body><form id="form1"></form></body></html>`),
+			message: "lectures table not found",
+		},
+		{
+			name:    "exam room",
+			fixture: "exam_room",
+			page: dataList(
+				[]string{"课程名称", "学分", "任课教师"},
+				[]string{"数据结构", "3.0", "王老师"},
+			),
+			message: "exam_room table: header 考试时间地点 not found",
+		},
+		{
+			name:    "credit",
+			fixture: "credit",
+			page: []byte(`<html><body><form id="form1"><span id="ContentPlaceHolder1_LB_kb">` +
+				`<table><tr><td></td><td>学科基础课</td></tr><tr><td>应获学分</td><td>40.0</td></tr></table>` +
+				`<table><tr><td>注</td></tr></table></span></form></body></html>`),
+			message: "credit table: row 应获学分 or 已获学分 not found",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv, stu := newLoggedIn(t)
+			srv.SetFixture(c.fixture, c.page)
+
+			var err error
+			switch c.fixture {
+			case "marks":
+				_, err = stu.GetMarks()
+			case "lectures":
+				_, err = stu.GetLectures()
+			case "exam_room":
+				_, err = stu.GetExamRoom(jwch.ExamRoomReq{Term: "202401"})
+			case "credit":
+				_, err = stu.GetCredit()
+			}
+			var e errno.ErrNo
+			if !errors.Is(err, errno.HTMLParseError) || !errors.As(err, &e) {
+				t.Fatalf("expected HTMLParseError, got %v", err)
+			}
+			if e.ErrorMsg != c.message {
+				t.Errorf("expected message %q, got %q", c.message, e.ErrorMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwchtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/west2-online/jwch"
+)
+
+// 模拟隧道接口默认接受的认证信息
+const (
+	DefaultTunnelKey = "tunnel-key"
+	DefaultTunnelPwd = "tunnel-pwd"
+)
+
+// TunnelServer 模拟青果网络的隧道查询接口，每条隧道都是一个本地的 HTTP 正向代理
+type TunnelServer struct {
+	*httptest.Server
+
+	AuthKey string
+	AuthPwd string
+
+	mu      sync.Mutex
+	tunnels []*tunnel
+	queries int
+}
+
+type tunnel struct {
+	server *httptest.Server
+	hits   int
+}
+
+// NewTunnelServer 启动带有 n 条隧道的模拟隧道接口，使用完毕后需要调用 Close
+func NewTunnelServer(n int) *TunnelServer {
+	t := &TunnelServer{AuthKey: DefaultTunnelKey, AuthPwd: DefaultTunnelPwd}
+	for i := 0; i < n; i++ {
+		t.tunnels = append(t.tunnels, t.newTunnel())
+	}
+	t.Server = httptest.NewServer(http.HandlerFunc(t.serveQuery))
+	return t
+}
+
+// Config 返回启用代理、指向该模拟接口的配置
+func (t *TunnelServer) Config() *jwch.Config {
+	return &jwch.Config{Proxy: jwch.ProxyConfig{
+		AuthKey:   t.AuthKey,
+		AuthPwd:   t.AuthPwd,
+		Enabled:   true,
+		TunnelURL: t.URL + "/query",
+	}}
+}
+
+// Provider 返回查询该模拟接口的 QingGuoProvider
+func (t *TunnelServer) Provider() *jwch.QingGuoProvider {
+	return &jwch.QingGuoProvider{AuthKey: t.AuthKey, AuthPwd: t.AuthPwd, URL: t.URL + "/query"}
+}
+
+// Tunnels 返回当前可用的隧道地址（host:port）
+func (t *TunnelServer) Tunnels() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	addrs := make([]string, len(t.tunnels))
+	for i, tun := range t.tunnels {
+		addrs[i] = tun.server.Listener.Addr().String()
+	}
+	return addrs
+}
+
+// Kill 关闭第 i 条隧道并用一条新隧道替换，之后的查询只会返回新隧道
+func (t *TunnelServer) Kill(i int) {
+	t.mu.Lock()
+	old := t.tunnels[i]
+	t.tunnels[i] = t.newTunnel()
+	t.mu.Unlock()
+	old.server.CloseClientConnections()
+	old.server.Close()
+}
+
+// Queries 返回隧道地址被查询的次数
+func (t *TunnelServer) Queries() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.queries
+}
+
+// Hits 返回经过隧道 addr 转发的请求数
+func (t *TunnelServer) Hits(addr string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tun := range t.tunnels {
+		if tun.server.Listener.Addr().String() == addr {
+			return tun.hits
+		}
+	}
+	return 0
+}
+
+// Close 关闭查询接口和所有隧道
+func (t *TunnelServer) Close() {
+	t.Server.Close()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tun := range t.tunnels {
+		tun.server.Close()
+	}
+}
+
+func (t *TunnelServer) serveQuery(w http.ResponseWriter, r *http.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queries++
+
+	resp := jwch.TunnelResponse{Code: "SUCCESS", RequestId: "jwchtest"}
+	if r.URL.Query().Get("key") != t.AuthKey || r.URL.Query().Get("pwd") != t.AuthPwd {
+		resp.Code = "AUTH_FAILED"
+	}
+	if resp.Code == "SUCCESS" {
+		for _, tun := range t.tunnels {
+			resp.Data = append(resp.Data, jwch.TunnelData{Server: tun.server.Listener.Addr().String(), Area: "350100"})
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (t *TunnelServer) newTunnel() *tunnel {
+	tun := &tunnel{}
+	tun.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.mu.Lock()
+		tun.hits++
+		t.mu.Unlock()
+		t.forward(w, r)
+	}))
+	return tun
+}
+
+// forward 校验代理认证后把请求原样转发给目标地址，不跟随重定向
+func (t *TunnelServer) forward(w http.ResponseWriter, r *http.Request) {
+	expected := "Basic " + base64.StdEncoding.EncodeToString([]byte(t.AuthKey+":"+t.AuthPwd))
+	if r.Header.Get("Proxy-Authorization") != expected {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="jwchtest"`)
+		w.WriteHeader(http.StatusProxyAuthRequired)
+		return
+	}
+	if !r.URL.IsAbs() || !strings.HasPrefix(r.URL.Scheme, "http") {
+		http.Error(w, "only absolute http requests are supported", http.StatusBadRequest)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.Header.Del("Proxy-Authorization")
+	out.Header.Del("Proxy-Connection")
+	resp, err := http.DefaultTransport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
@@ -17,56 +17,23 @@ limitations under the License.
 package jwch
 
 import (
-	"strings"
+	"context"
 	"time"
-
-	"github.com/west2-online/jwch/constants"
-	"github.com/west2-online/jwch/utils"
-
-	"github.com/antchfx/htmlquery"
 )
 
 // GetLectures 获取报名的讲座
 func (s *Student) GetLectures() ([]*Lecture, error) {
-	resp, err := s.GetWithIdentifier(constants.LectureURL)
-	if err != nil {
-		return nil, err
-	}
-
-	list := htmlquery.Find(htmlquery.FindOne(resp, `//*[@id="ContentPlaceHolder1_DataList_xxk"]/tbody`), "tr")
-
-	// 前三个元素分别为 空行 标题 表头
-	list = list[3:]
-
-	res := make([]*Lecture, 0)
-
-	for _, row := range list {
-		// 跳过空行
-		if strings.TrimSpace(htmlquery.SelectAttr(row, "style")) == "" {
-			continue
-		}
-
-		cells := htmlquery.Find(row, "./td")
-
-		res = append(res, &Lecture{
-			Category:         htmlquery.InnerText(cells[0]),
-			IssueNumber:      utils.SafeAtoi(htmlquery.InnerText(cells[1])),
-			Title:            htmlquery.InnerText(cells[2]),
-			Speaker:          htmlquery.InnerText(cells[3]),
-			Timestamp:        parseDateTime(htmlquery.InnerText(cells[4])),
-			Location:         htmlquery.InnerText(cells[5]),
-			AttendanceStatus: htmlquery.InnerText(cells[6]),
-		})
-	}
-
-	return res, nil
+	return s.GetLecturesCtx(context.Background())
 }
 
-func parseDateTime(dateTime string) int64 {
-	loc, _ := time.LoadLocation("Asia/Shanghai")
-	t, err := time.ParseInLocation("2006-01-02\u00A0\u00A015：04", dateTime, loc)
+// GetLecturesCtx 同 GetLectures，支持通过 ctx 取消请求
+func (s *Student) GetLecturesCtx(ctx context.Context) (_ []*Lecture, err error) {
+	ctx = ContextWithOperation(ctx, "GetLectures")
+	defer s.finish(ctx, time.Now(), &err)
+	resp, err := s.GetWithIdentifierCtx(ctx, s.endpoints.LectureURL)
 	if err != nil {
-		return 0
+		return nil, err
 	}
-	return t.UnixMilli()
+
+	return s.parser().Lectures(resp)
 }
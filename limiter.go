@@ -0,0 +1,212 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Limiter 限制发往教务处的请求速率和同时进行的请求数
+// 同一个 Limiter 可以通过 WithLimiter 在多个 Student 之间共享，从而限制整个进程的请求量
+type Limiter struct {
+	rate  float64 // 每秒允许的请求数，<= 0 表示不限制
+	burst float64 // 令牌桶容量
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	slots chan struct{} // 同时进行的请求数，为 nil 表示不限制
+
+	statsMu   sync.Mutex
+	stats     LimiterStats
+	waitCount int
+}
+
+// LimiterStats 限流器的统计数据
+type LimiterStats struct {
+	Requests  uint64        // 已放行的请求数
+	InFlight  int           // 正在进行的请求数
+	Waiting   int           // 正在排队的请求数
+	TotalWait time.Duration // 所有请求排队的总时长
+	MaxWait   time.Duration // 单个请求排队的最长时长
+}
+
+// NewLimiter 创建限流器
+// rate 为每秒允许的请求数，burst 为允许的突发请求数（小于 1 时按 1 处理），maxInFlight 为同时进行的最大请求数
+// rate <= 0 表示不限制速率，maxInFlight <= 0 表示不限制并发
+func NewLimiter(rate float64, burst, maxInFlight int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	l := &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+	if maxInFlight > 0 {
+		l.slots = make(chan struct{}, maxInFlight)
+	}
+	return l
+}
+
+// Wait 等待直到允许发出一个请求，请求结束后必须调用返回的 release
+// ctx 被取消时返回 ContextCanceledError
+func (l *Limiter) Wait(ctx context.Context) (release func(), err error) {
+	start := time.Now()
+	l.addWaiting(1)
+	defer l.addWaiting(-1)
+
+	// 先占用并发名额再取令牌，保证令牌只在请求真正发出时消耗
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, contextError(ctx)
+		}
+	}
+	if err = l.take(ctx); err != nil {
+		l.releaseSlot()
+		return nil, err
+	}
+
+	l.admit(time.Since(start))
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.statsMu.Lock()
+			l.stats.InFlight--
+			l.statsMu.Unlock()
+			l.releaseSlot()
+		})
+	}, nil
+}
+
+// Stats 返回当前的统计数据
+func (l *Limiter) Stats() LimiterStats {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	stats := l.stats
+	stats.Waiting = l.waitCount
+	return stats
+}
+
+// Transport 返回经过限流的 RoundTripper，next 为 nil 时使用 http.DefaultTransport
+// 并发名额在响应体被关闭后才会释放
+func (l *Limiter) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &limitedTransport{limiter: l, next: next}
+}
+
+// take 预订一个令牌，令牌不足时等待到令牌补足的时刻
+func (l *Limiter) take(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// 归还预订的令牌
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return contextError(ctx)
+	}
+}
+
+func (l *Limiter) releaseSlot() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+func (l *Limiter) addWaiting(delta int) {
+	l.statsMu.Lock()
+	l.waitCount += delta
+	l.statsMu.Unlock()
+}
+
+func (l *Limiter) admit(wait time.Duration) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	l.stats.Requests++
+	l.stats.InFlight++
+	l.stats.TotalWait += wait
+	if wait > l.stats.MaxWait {
+		l.stats.MaxWait = wait
+	}
+}
+
+type limitedTransport struct {
+	limiter *Limiter
+	next    http.RoundTripper
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.limiter.Wait(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releaseOnClose 响应体关闭时释放并发名额
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.release()
+	return err
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+func TestLimiterMaxInFlight(t *testing.T) {
+	var current, peak atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := current.Add(1)
+		defer current.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer backend.Close()
+
+	limiter := NewLimiter(0, 1, 2)
+	client := &http.Client{Transport: limiter.Transport(nil)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(backend.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("expected at most 2 requests in flight, got %d", p)
+	}
+	stats := limiter.Stats()
+	if stats.Requests != 10 || stats.InFlight != 0 || stats.Waiting != 0 || stats.MaxWait <= 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	limiter := NewLimiter(50, 1, 0)
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		release, err := limiter.Wait(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	// 第一个请求使用桶中的令牌，其余 5 个每个至少等待 20ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("rate not limited, 6 requests took %v", elapsed)
+	}
+}
+
+func TestLimiterCanceled(t *testing.T) {
+	limiter := NewLimiter(0, 1, 1)
+	release, err := limiter.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err = limiter.Wait(ctx); err == nil || errno.ConvertErr(err).ErrorCode != errno.ContextCanceledErrorCode {
+		t.Errorf("expected ContextCanceledError, got %v", err)
+	}
+
+	// release 可以重复调用，名额只会归还一次
+	release()
+	release()
+	if stats := limiter.Stats(); stats.InFlight != 0 || stats.Requests != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
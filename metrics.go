@@ -0,0 +1,304 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+// Outcome 操作结果的分类，用于区分教务处故障和解析失败
+type Outcome string
+
+const (
+	OutcomeSuccess            Outcome = "success"              // 成功
+	OutcomeCookieError        Outcome = "cookie_error"         // 会话过期或 id 错误
+	OutcomeEvaluationNotFound Outcome = "evaluation_not_found" // 需要先完成评议
+	OutcomeAuthError          Outcome = "auth_error"           // 登录失败、账号冲突等其他鉴权错误
+	OutcomeNetworkError       Outcome = "network_error"        // 教务处、代理或验证码服务等上游网络异常
+	OutcomeMaintenance        Outcome = "maintenance"          // 教务处系统维护
+	OutcomeAlert              Outcome = "alert"                // 教务处弹出提示（欠费等），没有返回正常页面
+	OutcomeParseError         Outcome = "parse_error"          // 收到了响应但无法解析
+	OutcomeCanceled           Outcome = "canceled"             // 调用方取消或超时
+	OutcomeOther              Outcome = "other"                // 其他错误
+)
+
+// ClassifyOutcome 返回 err 对应的结果分类
+func ClassifyOutcome(err error) Outcome {
+	if err == nil {
+		return OutcomeSuccess
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return OutcomeCanceled
+	}
+
+	var e errno.ErrNo
+	if !errors.As(err, &e) {
+		var netErr net.Error
+		if errors.As(err, &netErr) {
+			return OutcomeNetworkError
+		}
+		return OutcomeOther
+	}
+	switch e.ErrorCode {
+	case errno.ContextCanceledErrorCode:
+		return OutcomeCanceled
+	case errno.JwchNetworkErrorCode, errno.ProxyUnavailableErrorCode:
+		return OutcomeNetworkError
+	case errno.NeedEvaluationErrorCode:
+		return OutcomeEvaluationNotFound
+	case errno.MaintenanceErrorCode:
+		return OutcomeMaintenance
+	case errno.TuitionArrearsErrorCode, errno.JwchAlertErrorCode:
+		return OutcomeAlert
+	case errno.HTTPQueryErrorCode:
+		// 解析失败与验证码服务、代理等上游请求失败共用错误码，按预定义错误区分
+		if errors.Is(e, errno.HTMLParseError) || errors.Is(e, errno.ExamTimeParseError) {
+			return OutcomeParseError
+		}
+		return OutcomeNetworkError
+	case errno.UnexpectedRedirectErrorCode:
+		return OutcomeParseError
+	case errno.WrongPasswordErrorCode, errno.WrongCaptchaErrorCode, errno.AccountNotFoundErrorCode, errno.AccountLockedErrorCode:
+		return OutcomeAuthError
+	case errno.AuthorizationFailedErrCode:
+		if errors.Is(e, errno.CookieError) {
+			return OutcomeCookieError
+		}
+		return OutcomeAuthError
+	}
+	return OutcomeOther
+}
+
+// MetricsCollector 接收每次公开操作（GetMarks、Login 等）的结果
+// 同时实现 RequestObserver 时，还会收到每个实际发出的 HTTP 请求
+type MetricsCollector interface {
+	ObserveOperation(op string, outcome Outcome, duration time.Duration)
+}
+
+// RequestObserver 接收每个实际发出的 HTTP 请求（包括重试），请求失败时 statusCode 为 0
+type RequestObserver interface {
+	ObserveRequest(op string, statusCode int, duration time.Duration)
+}
+
+// requestObserverHook 将 RequestObserver 包装为 Hook
+func requestObserverHook(obs RequestObserver) Hook {
+	return Hook{
+		AfterResponse: func(ctx context.Context, resp *ResponseInfo) {
+			obs.ObserveRequest(resp.Op, resp.StatusCode, resp.Latency)
+		},
+		OnError: func(ctx context.Context, resp *ResponseInfo) {
+			obs.ObserveRequest(resp.Op, 0, resp.Latency)
+		},
+	}
+}
+
+// DefaultMetricsBuckets 默认的耗时分桶（秒），教务处响应较慢，上限设置得比较大
+var DefaultMetricsBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// HistogramStats 一组标签下的计数和耗时分布
+type HistogramStats struct {
+	Count   uint64
+	Sum     float64  // 耗时之和（秒）
+	Buckets []uint64 // 与 MetricsSnapshot.Buckets 一一对应的累计计数
+}
+
+// OperationStats 一个操作在某个结果分类下的统计
+type OperationStats struct {
+	Op      string
+	Outcome Outcome
+	HistogramStats
+}
+
+// RequestStats 一个操作下某个状态码的 HTTP 请求统计，Code 为状态码或 error
+type RequestStats struct {
+	Op   string
+	Code string
+	HistogramStats
+}
+
+// MetricsSnapshot Metrics 在某一时刻的快照，按标签排序
+type MetricsSnapshot struct {
+	Buckets    []float64
+	Operations []OperationStats
+	Requests   []RequestStats
+}
+
+// Metrics 内存中的指标，实现了 MetricsCollector 和 RequestObserver
+// 通过 ServeHTTP 或 WritePrometheus 输出 Prometheus 文本格式，不依赖 Prometheus 客户端库；
+// 需要注册到 prometheus.Registry 时，可以在 Collect 中将 Snapshot 转换为 ConstHistogram
+type Metrics struct {
+	buckets []float64
+
+	mu         sync.Mutex
+	operations map[[2]string]*histogram
+	requests   map[[2]string]*histogram
+}
+
+type histogram struct {
+	count   uint64
+	sum     float64
+	buckets []uint64 // 非累计计数，输出时再累加
+}
+
+// NewMetrics 创建 Metrics，buckets 为空时使用 DefaultMetricsBuckets
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultMetricsBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return &Metrics{
+		buckets:    slices.Compact(buckets),
+		operations: map[[2]string]*histogram{},
+		requests:   map[[2]string]*histogram{},
+	}
+}
+
+func (m *Metrics) ObserveOperation(op string, outcome Outcome, duration time.Duration) {
+	m.observe(m.operations, [2]string{op, string(outcome)}, duration)
+}
+
+func (m *Metrics) ObserveRequest(op string, statusCode int, duration time.Duration) {
+	code := "error"
+	if statusCode > 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	m.observe(m.requests, [2]string{op, code}, duration)
+}
+
+func (m *Metrics) observe(series map[[2]string]*histogram, key [2]string, duration time.Duration) {
+	seconds := duration.Seconds()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, ok := series[key]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(m.buckets))}
+		series[key] = h
+	}
+	h.count++
+	h.sum += seconds
+	if i, _ := slices.BinarySearch(m.buckets, seconds); i < len(m.buckets) {
+		h.buckets[i]++
+	}
+}
+
+// Snapshot 返回当前的统计
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snap := MetricsSnapshot{Buckets: slices.Clone(m.buckets)}
+	for _, key := range sortedKeys(m.operations) {
+		snap.Operations = append(snap.Operations, OperationStats{Op: key[0], Outcome: Outcome(key[1]), HistogramStats: m.operations[key].stats()})
+	}
+	for _, key := range sortedKeys(m.requests) {
+		snap.Requests = append(snap.Requests, RequestStats{Op: key[0], Code: key[1], HistogramStats: m.requests[key].stats()})
+	}
+	return snap
+}
+
+func (h *histogram) stats() HistogramStats {
+	stats := HistogramStats{Count: h.count, Sum: h.sum, Buckets: make([]uint64, len(h.buckets))}
+	var total uint64
+	for i, n := range h.buckets {
+		total += n
+		stats.Buckets[i] = total
+	}
+	return stats
+}
+
+func sortedKeys(series map[[2]string]*histogram) [][2]string {
+	keys := make([][2]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b [2]string) int {
+		if c := strings.Compare(a[0], b[0]); c != 0 {
+			return c
+		}
+		return strings.Compare(a[1], b[1])
+	})
+	return keys
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出全部指标
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	snap := m.Snapshot()
+	var b strings.Builder
+
+	writeHeader(&b, "jwch_operations_total", "counter", "Total number of JWCH operations by outcome.")
+	for _, s := range snap.Operations {
+		fmt.Fprintf(&b, "jwch_operations_total{%s} %d\n", labels("op", s.Op, "outcome", string(s.Outcome)), s.Count)
+	}
+	writeHeader(&b, "jwch_operation_duration_seconds", "histogram", "Duration of JWCH operations in seconds.")
+	for _, s := range snap.Operations {
+		writeHistogram(&b, "jwch_operation_duration_seconds", snap.Buckets, s.HistogramStats, "op", s.Op, "outcome", string(s.Outcome))
+	}
+	writeHeader(&b, "jwch_http_requests_total", "counter", "Total number of HTTP requests sent to JWCH by status code.")
+	for _, s := range snap.Requests {
+		fmt.Fprintf(&b, "jwch_http_requests_total{%s} %d\n", labels("op", s.Op, "code", s.Code), s.Count)
+	}
+	writeHeader(&b, "jwch_http_request_duration_seconds", "histogram", "Duration of HTTP requests sent to JWCH in seconds.")
+	for _, s := range snap.Requests {
+		writeHistogram(&b, "jwch_http_request_duration_seconds", snap.Buckets, s.HistogramStats, "op", s.Op, "code", s.Code)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP 输出 Prometheus 文本格式，可以直接挂载到 /metrics
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WritePrometheus(w)
+}
+
+func writeHeader(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeHistogram(b *strings.Builder, name string, bounds []float64, h HistogramStats, kv ...string) {
+	base := labels(kv...)
+	for i, bound := range bounds {
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, base, strconv.FormatFloat(bound, 'g', -1, 64), h.Buckets[i])
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, base, h.Count)
+	fmt.Fprintf(b, "%s_sum{%s} %s\n", name, base, strconv.FormatFloat(h.Sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count{%s} %d\n", name, base, h.Count)
+}
+
+// labelEscaper 转义标签值中的反斜杠、双引号和换行
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(kv ...string) string {
+	parts := make([]string, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		parts = append(parts, kv[i]+`="`+labelEscaper.Replace(kv[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+func TestMetricsBuckets(t *testing.T) {
+	metrics := NewMetrics(1, 0.1)
+	metrics.ObserveOperation("op\"1", OutcomeSuccess, 100*time.Millisecond)
+	metrics.ObserveOperation("op\"1", OutcomeSuccess, 500*time.Millisecond)
+	metrics.ObserveOperation("op\"1", OutcomeSuccess, 2*time.Second)
+
+	var b strings.Builder
+	if err := metrics.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`jwch_operation_duration_seconds_bucket{op="op\"1",outcome="success",le="0.1"} 1`,
+		`jwch_operation_duration_seconds_bucket{op="op\"1",outcome="success",le="1"} 2`,
+		`jwch_operation_duration_seconds_bucket{op="op\"1",outcome="success",le="+Inf"} 3`,
+		`jwch_operation_duration_seconds_sum{op="op\"1",outcome="success"} 2.6`,
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("missing %q in:\n%s", line, b.String())
+		}
+	}
+}
+
+func TestClassifyOutcome(t *testing.T) {
+	cases := []struct {
+		err  error
+		want Outcome
+	}{
+		{nil, OutcomeSuccess},
+		{errno.CookieError, OutcomeCookieError},
+		{errno.AccountConflictError, OutcomeAuthError},
+		{errno.WrongPasswordError, OutcomeAuthError},
+		{errno.MaintenanceError, OutcomeMaintenance},
+		{errno.TuitionArrearsError.WithMessage("学费未缴清"), OutcomeAlert},
+		{errno.JwchAlertError, OutcomeAlert},
+		{errno.UnexpectedRedirectError, OutcomeParseError},
+		{errno.EvaluationNotFoundError, OutcomeEvaluationNotFound},
+		{errno.JwchNetworkError.WithAttempts(3), OutcomeNetworkError},
+		{errno.HTMLParseError.WithMessage("marks table not found"), OutcomeParseError},
+		{errno.ExamTimeParseError.WithMessage("待定"), OutcomeParseError},
+		{errno.HTTPQueryError.WithMessage("automatic code identification failed"), OutcomeNetworkError},
+		{errno.ContextCanceledError, OutcomeCanceled},
+		{context.DeadlineExceeded, OutcomeCanceled},
+		{errors.New("unknown"), OutcomeOther},
+	}
+	for _, c := range cases {
+		if got := ClassifyOutcome(c.err); got != c.want {
+			t.Errorf("ClassifyOutcome(%v) = %s, want %s", c.err, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+// Shanghai 教务处使用的时区，系统缺少时区数据时使用固定的 UTC+8
+var Shanghai = func() *time.Location {
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return time.FixedZone("CST", 8*60*60)
+	}
+	return loc
+}()
+
+// TermStartDate 返回学期的开始日期（Asia/Shanghai），term 格式与课表一致，例如 202401
+func (c *SchoolCalendar) TermStartDate(term string) (time.Time, error) {
+	for _, t := range c.Terms {
+		if t.Term != term {
+			continue
+		}
+		start, err := time.ParseInLocation("2006-01-02", t.StartDate, Shanghai)
+		if err != nil {
+			return time.Time{}, errno.HTMLParseError.WithErr(err)
+		}
+		return start, nil
+	}
+	return time.Time{}, errno.ParamError.WithMessage("term not found in school calendar: " + term)
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ScoreKind 成绩的记分方式
+type ScoreKind string
+
+const (
+	ScoreNumeric   ScoreKind = "numeric"    // 百分制
+	ScoreFiveLevel ScoreKind = "five_level" // 五级制：优秀、良好、中等、及格、不及格
+	ScorePassFail  ScoreKind = "pass_fail"  // 二级制：合格、不合格
+	ScoreAbsent    ScoreKind = "absent"     // 缺考
+	ScoreExempt    ScoreKind = "exempt"     // 免修
+	ScoreUnknown   ScoreKind = "unknown"    // 未出成绩或无法识别
+)
+
+// 五级制成绩是否通过，只有不及格不通过
+var fiveLevelPassed = map[string]bool{
+	"优秀":  true,
+	"良好":  true,
+	"中等":  true,
+	"及格":  true,
+	"不及格": false,
+}
+
+// FiveLevelScores 五级制成绩折算的百分制分数，默认为空：教务处页面不提供折算值，库中不内置未经核实的数值
+// 需要按百分制比较或求平均时，由调用方在解析前按学校现行的学籍管理规定填写，例如 FiveLevelScores["优秀"] = ...
+// 未填写的等级 Value 为 0、HasValue 返回 false；课程绩点以页面上的绩点列为准，见 MarkV2.GPA
+var FiveLevelScores = map[string]float64{}
+
+// 二级制成绩只区分是否通过，通过后只计学分，不折算为百分制，也不参与平均分和绩点计算
+var passFailScores = map[string]bool{
+	"合格":  true,
+	"通过":  true,
+	"不合格": false,
+	"不通过": false,
+}
+
+// Score 解析后的成绩
+type Score struct {
+	Raw    string    `json:"raw"`    // 页面上的原始文本
+	Kind   ScoreKind `json:"kind"`   // 记分方式
+	Value  float64   `json:"value"`  // 百分制分数，五级制按 FiveLevelScores 折算，未折算的五级制、二级制、缺考、免修等为 0，见 HasValue
+	Passed bool      `json:"passed"` // 是否通过，免修视为通过
+}
+
+// HasValue 成绩是否有百分制分数，未折算的五级制、二级制、缺考、免修等不参与平均分计算
+func (s Score) HasValue() bool {
+	if s.Kind == ScoreFiveLevel {
+		_, ok := FiveLevelScores[strings.TrimSpace(s.Raw)]
+		return ok
+	}
+	return s.Kind == ScoreNumeric
+}
+
+// ParseScore 识别成绩的记分方式
+func ParseScore(raw string) Score {
+	text := strings.TrimSpace(raw)
+	score := Score{Raw: raw, Kind: ScoreUnknown}
+	if v, err := strconv.ParseFloat(text, 64); err == nil {
+		score.Kind, score.Value, score.Passed = ScoreNumeric, v, v >= 60
+		return score
+	}
+	if passed, ok := fiveLevelPassed[text]; ok {
+		score.Kind, score.Value, score.Passed = ScoreFiveLevel, FiveLevelScores[text], passed
+		return score
+	}
+	if passed, ok := passFailScores[text]; ok {
+		score.Kind, score.Passed = ScorePassFail, passed
+		return score
+	}
+	switch text {
+	case "缺考", "旷考":
+		score.Kind = ScoreAbsent
+	case "免修", "免考":
+		score.Kind, score.Passed = ScoreExempt, true
+	}
+	return score
+}
+
+// MarkType 修读类别
+type MarkType string
+
+const (
+	MarkTypeMajor MarkType = "major" // 主修
+	MarkTypeMinor MarkType = "minor" // 辅修
+	MarkTypeOther MarkType = "other" // 其他
+)
+
+// ParseMarkType 识别修读类别，无法识别时返回 MarkTypeOther
+func ParseMarkType(raw string) MarkType {
+	switch strings.TrimSpace(raw) {
+	case "主修":
+		return MarkTypeMajor
+	case "辅修":
+		return MarkTypeMinor
+	}
+	return MarkTypeOther
+}
+
+// ElectiveType 选课类型
+type ElectiveType string
+
+const (
+	ElectiveRequired   ElectiveType = "required"   // 必修
+	ElectiveRestricted ElectiveType = "restricted" // 限选
+	ElectiveOptional   ElectiveType = "optional"   // 选修、任选、公选
+	ElectiveOther      ElectiveType = "other"      // 其他
+)
+
+// ParseElectiveType 识别选课类型，无法识别时返回 ElectiveOther
+func ParseElectiveType(raw string) ElectiveType {
+	switch strings.TrimSpace(raw) {
+	case "必修":
+		return ElectiveRequired
+	case "限选":
+		return ElectiveRestricted
+	case "选修", "任选", "公选":
+		return ElectiveOptional
+	}
+	return ElectiveOther
+}
+
+// ExamType 考试类别
+type ExamType string
+
+const (
+	ExamTypeExam       ExamType = "exam"       // 考试
+	ExamTypeAssessment ExamType = "assessment" // 考查
+	ExamTypeOther      ExamType = "other"      // 其他
+)
+
+// ParseExamType 识别考试类别，无法识别时返回 ExamTypeOther
+func ParseExamType(raw string) ExamType {
+	switch strings.TrimSpace(raw) {
+	case "考试":
+		return ExamTypeExam
+	case "考查":
+		return ExamTypeAssessment
+	}
+	return ExamTypeOther
+}
+
+// MarkV2 类型化的成绩，由 Mark.V2 转换得到，原始字符串仍然保留在 Mark 中
+type MarkV2 struct {
+	Type          MarkType      `json:"type"`           // 修读类别
+	Semester      string        `json:"semester"`       // 开课学期
+	Name          string        `json:"name"`           // 课程名称
+	Credits       float64       `json:"credit"`         // 计划学分
+	Score         Score         `json:"score"`          // 得分
+	GPA           float64       `json:"GPA"`            // 绩点
+	EarnedCredits float64       `json:"earned_credits"` // 得到学分
+	ElectiveType  ElectiveType  `json:"electivetype"`   // 选课类型
+	ExamType      ExamType      `json:"examtype"`       // 考试类别
+	Teacher       string        `json:"teacher"`        // 任课教师
+	Classroom     string        `json:"classroom"`      // 上课时间地点
+	ExamTime      string        `json:"examtime"`       // 考试时间地点
+	Exam          *ExamSchedule `json:"exam,omitempty"` // 考试时间地点，未安排或无法解析时为 nil
+}
+
+// V2 转换为 MarkV2，学分和绩点为空或无法解析时为 0
+func (m *Mark) V2() *MarkV2 {
+	return &MarkV2{
+		Type:          ParseMarkType(m.Type),
+		Semester:      m.Semester,
+		Name:          m.Name,
+		Credits:       parseDecimal(m.Credits),
+		Score:         ParseScore(m.Score),
+		GPA:           parseDecimal(m.GPA),
+		EarnedCredits: parseDecimal(m.EarnedCredits),
+		ElectiveType:  ParseElectiveType(m.ElectiveType),
+		ExamType:      ParseExamType(m.ExamType),
+		Teacher:       m.Teacher,
+		Classroom:     m.Classroom,
+		ExamTime:      m.ExamTime,
+		Exam:          m.Exam,
+	}
+}
+
+// MarksV2 批量转换为 MarkV2
+func MarksV2(marks []*Mark) []*MarkV2 {
+	res := make([]*MarkV2, 0, len(marks))
+	for _, m := range marks {
+		res = append(res, m.V2())
+	}
+	return res
+}
+
+func parseDecimal(raw string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
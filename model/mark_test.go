@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model_test
+
+import (
+	"testing"
+
+	"github.com/west2-online/jwch/model"
+)
+
+func TestParseScore(t *testing.T) {
+	cases := []struct {
+		raw      string
+		kind     model.ScoreKind
+		value    float64
+		passed   bool
+		hasValue bool
+	}{
+		{"88", model.ScoreNumeric, 88, true, true},
+		{" 59.5 ", model.ScoreNumeric, 59.5, false, true},
+		{"优秀", model.ScoreFiveLevel, 0, true, false},
+		{"不及格", model.ScoreFiveLevel, 0, false, false},
+		{"合格", model.ScorePassFail, 0, true, false},
+		{"不合格", model.ScorePassFail, 0, false, false},
+		{"缺考", model.ScoreAbsent, 0, false, false},
+		{"免修", model.ScoreExempt, 0, true, false},
+		{"", model.ScoreUnknown, 0, false, false},
+	}
+	for _, c := range cases {
+		s := model.ParseScore(c.raw)
+		if s.Raw != c.raw || s.Kind != c.kind || s.Value != c.value || s.Passed != c.passed || s.HasValue() != c.hasValue {
+			t.Errorf("%q: unexpected score %+v", c.raw, s)
+		}
+	}
+}
+
+// 五级制的折算值由调用方填写
+func TestParseScoreFiveLevelScores(t *testing.T) {
+	model.FiveLevelScores["良好"] = 80
+	defer delete(model.FiveLevelScores, "良好")
+
+	s := model.ParseScore("良好")
+	if s.Kind != model.ScoreFiveLevel || s.Value != 80 || !s.Passed || !s.HasValue() {
+		t.Errorf("unexpected score %+v", s)
+	}
+	if s = model.ParseScore("中等"); s.HasValue() || !s.Passed {
+		t.Errorf("unexpected score %+v", s)
+	}
+}
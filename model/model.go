@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package model 教务处页面解析出的数据结构，由 jwch 和 parse 包共用
+// jwch 包中有同名的类型别名，调用方通常不需要直接引用本包
+package model
+
+import "time"
+
+// 校区名称
+const (
+	CampusQiShan   = "旗山校区"
+	CampusTongPan  = "铜盘校区"
+	CampusYiShan   = "怡山校区"
+	CampusXiaMen   = "厦门工艺美院"
+	CampusQuanGang = "泉港校区"
+	CampusJinJiang = "晋江校区"
+)
+
+// 学生信息详情
+type StudentDetail struct {
+	Name             string `json:"name"`              // 姓名
+	Sex              string `json:"sex"`               // 性别
+	Birthday         string `json:"birthday"`          // 出生日期
+	Phone            string `json:"phont"`             // 手机号
+	Email            string `json:"email"`             // 邮箱
+	College          string `json:"college"`           // 学院
+	Grade            string `json:"grade"`             // 年级
+	StatusChanges    string `json:"status_change"`     // 学籍异动与奖励
+	Major            string `json:"major"`             // 专业
+	Counselor        string `json:"counselor"`         // 辅导员
+	ExamineeCategory string `json:"examinee_category"` // 考生类别
+	Nationality      string `json:"nationality"`       // 民族
+	Country          string `json:"country"`           // 国别
+	PoliticalStatus  string `json:"political_status"`  // 政治面貌
+	Source           string `json:"source"`            // 生源地
+}
+
+// 课程信息
+type Course struct {
+	Type       string `json:"type"`       // 修读类别
+	Name       string `json:"name"`       // 课程名称
+	Syllabus   string `json:"syllabus"`   // 课程大纲
+	LessonPlan string `json:"lessonplan"` // 课程计划
+	// PaymentStatus string `json:"paymentstatus"` // 缴费状态
+	Credits               string                       `json:"credit"`                // 学分
+	ElectiveType          string                       `json:"electivetype"`          // 选课类型
+	ExamType              string                       `json:"examtype"`              // 考试类别
+	Teacher               string                       `json:"teacher"`               // 任课教师
+	ScheduleRules         []CourseScheduleRule         `json:"scheduleRules"`         // 上课时间地点规则
+	FullWeekScheduleRules []CourseFullWeekScheduleRule `json:"fullWeekScheduleRules"` // 整周课程上课时间地点规则
+	AdjustRules           []CourseAdjustRule           `json:"adjustRules"`           // 调课规则
+	RawScheduleRules      string                       `json:"rawScheduleRules"`      // 上课时间地点（原始文本）
+	RawExamTime           string                       `json:"rawExamTime"`           // 考试时间地点（原始文本）
+	Exam                  *ExamSchedule                `json:"exam,omitempty"`        // 考试时间地点，未安排或无法解析时为 nil
+	RawAdjust             string                       `json:"rawAdjust"`             // 调课信息（原始文本）
+	Remark                string                       `json:"remark"`                // 备注
+}
+
+// 周内课程的上课时间地点规则
+type CourseScheduleRule struct {
+	Location     string `json:"location"`     // 上课地点
+	StartClass   int    `json:"startClass"`   // 开始节数
+	EndClass     int    `json:"endClass"`     // 结束节数
+	StartWeek    int    `json:"startWeek"`    // 开始周
+	EndWeek      int    `json:"endWeek"`      // 结束周
+	Weekday      int    `json:"weekday"`      // 星期几
+	Single       bool   `json:"single"`       // 单周 (PS: 为啥不用 odd)
+	Double       bool   `json:"double"`       // 双周 (PS: 为啥不用 even)
+	Adjust       bool   `json:"adjust"`       // 调课
+	FromFullWeek bool   `json:"fromFullWeek"` // 是否来自整周课程
+}
+
+// 整周课程的上课时间地点规则
+type CourseFullWeekScheduleRule struct {
+	StartWeek    int `json:"startWeek"`    // 开始周
+	StartWeekDay int `json:"startWeekDay"` // 开始周在星期几
+	EndWeek      int `json:"endWeek"`      // 结束周
+	EndWeekDay   int `json:"endWeekDay"`   // 结束周在星期几
+}
+
+// 调课规则
+type CourseAdjustRule struct {
+	OldWeek       int `json:"oldWeek"`       // 原-周次
+	OldWeekday    int `json:"oldWeekday"`    // 原-星期几
+	OldStartClass int `json:"oldStartClass"` // 原-开始节数
+	OldEndClass   int `json:"oldEndClass"`   // 原-结束节数
+
+	Canceled      bool   `json:"canceled"`      // 是否取消
+	NewWeek       int    `json:"newWeek"`       // 新-周次
+	NewWeekday    int    `json:"newWeekday"`    // 新-星期几
+	NewStartClass int    `json:"newStartClass"` // 新-开始节数
+	NewEndClass   int    `json:"newEndClass"`   // 新-结束节数
+	NewLocation   string `json:"newLocation"`   // 新-上课地点
+}
+
+type Mark struct {
+	Type          string        `json:"type"`           // 修读类别
+	Semester      string        `json:"semester"`       // 开课学期
+	Name          string        `json:"name"`           // 课程名称
+	Credits       string        `json:"credit"`         // 计划学分
+	Score         string        `json:"score"`          // 得分
+	GPA           string        `json:"GPA"`            // 绩点
+	EarnedCredits string        `json:"earned_credits"` // 得到学分
+	ElectiveType  string        `json:"electivetype"`   // 选课类型
+	ExamType      string        `json:"examtype"`       // 考试类别
+	Teacher       string        `json:"teacher"`        // 任课教师
+	Classroom     string        `json:"classroom"`      // 上课时间地点
+	ExamTime      string        `json:"examtime"`       // 考试时间地点
+	Exam          *ExamSchedule `json:"exam,omitempty"` // 考试时间地点，未安排或无法解析时为 nil
+}
+
+type CalTermEvents struct {
+	TermId     string         `json:"termId"`     // 学期ID
+	Term       string         `json:"term"`       // 学期
+	SchoolYear string         `json:"schoolYear"` // 学年
+	Events     []CalTermEvent `json:"events"`     // 事件
+}
+
+type CalTermEvent struct {
+	Name      string `json:"name"`      // 事件名称
+	StartDate string `json:"startDate"` // 开始日期 格式:2024-08-26
+	EndDate   string `json:"endDate"`   // 结束日期 格式:2025-01-17
+}
+
+type CreditStatistics struct {
+	Type  string // 学分类型
+	Gain  string // 已获得
+	Total string // 应获学分
+}
+
+type GPAData struct {
+	Type  string
+	Value string
+}
+
+type GPABean struct {
+	Time string // 绩点计算时间
+	Data []GPAData
+}
+
+type UnifiedExam struct {
+	Name  string
+	Score string
+	Term  string
+}
+
+type ExamRoomInfo struct {
+	CourseName string        // 课程名称
+	Credit     string        // 学分
+	Teacher    string        // 任课教师
+	Date       string        // 考试日期，页面原文，例如 2025年1月8日
+	Time       string        // 考试时间，页面原文，例如 8:30-10:30
+	Location   string        // 考试地点
+	Exam       *ExamSchedule // 考试时间地点，未安排或无法解析时为 nil
+}
+
+// 考试安排
+type ExamSchedule struct {
+	Start    time.Time `json:"start"`    // 开始时间（Asia/Shanghai）
+	End      time.Time `json:"end"`      // 结束时间（Asia/Shanghai）
+	Campus   string    `json:"campus"`   // 校区，例如 旗山校区
+	Building string    `json:"building"` // 楼栋，例如 东3
+	Room     string    `json:"room"`     // 教室，例如 101
+	Location string    `json:"location"` // 地点原文，例如 旗山东3-101
+	Raw      string    `json:"raw"`      // 考试时间地点原文
+}
+
+// Lecture 讲座信息
+type Lecture struct {
+	Category         string `json:"category"`          // 讲座类别
+	IssueNumber      int    `json:"issue_number"`      // 期号
+	Title            string `json:"title"`             // 讲座题目
+	Speaker          string `json:"speaker"`           // 主讲人
+	Timestamp        int64  `json:"timestamp"`         // 时间戳
+	Location         string `json:"location"`          // 地点
+	AttendanceStatus string `json:"attendance_status"` // 听取讲座情况
+}
+
+// 校历
+type SchoolCalendar struct {
+	CurrentTerm string    `json:"currentTerm"` // 当前学期
+	Terms       []CalTerm `json:"terms"`       // 学期信息
+}
+
+type CalTerm struct {
+	TermId     string `json:"termId"`     // 学期ID
+	SchoolYear string `json:"schoolYear"` // 学年
+	Term       string `json:"term"`       // 学期
+	StartDate  string `json:"startDate"`  // 开始日期 格式:2024-08-26
+	EndDate    string `json:"endDate"`    // 结束日期 格式:2025-01-17
+}
+
+type NoticeInfo struct {
+	Title    string // 通知标题
+	URL      string // 通知链接
+	Date     string // 通知日期
+	WbTreeId string // 部门ID (1035: 综合科; 1036: 教学类型; 1037: 教研教改; 1038: 计划科; 1139: 实践科; 1140: 质量办; 1141: 电教中心; 1142: 教材中心; 1143: 铜盘校区管理科)
+	WbNewsId string // 新闻ID
+}
+
+type NoticeDetail struct {
+	NoticeInfo
+
+	Content string // 通知内容
+}
+
+// LocateDate 当前时间
+type LocateDate struct {
+	Week string
+	Year string
+	Term string
+}
+
+// Option 下拉框中的选项
+type Option struct {
+	Text  string // 显示的文字
+	Value string // 提交的值
+}
+
+// 学期信息
+type Term struct {
+	Terms           []string `json:"terms"`           // 学期数量
+	ViewState       string   `json:"viewstate"`       // 课表必要信息
+	EventValidation string   `json:"eventvalidation"` // 课表必要信息
+}
@@ -17,43 +17,72 @@ limitations under the License.
 package jwch
 
 import (
+	"context"
 	"fmt"
-	"net/url"
-	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/antchfx/htmlquery"
-	"golang.org/x/net/html"
+	"github.com/west2-online/jwch/errno"
 
-	"github.com/west2-online/jwch/constants"
+	"github.com/antchfx/htmlquery"
 )
 
 func (s *Student) GetNoticeInfo(req *NoticeInfoReq) (list []*NoticeInfo, totalPages int, err error) {
+	return s.GetNoticeInfoCtx(context.Background(), req)
+}
+
+// GetNoticeInfoCtx 同 GetNoticeInfo，支持通过 ctx 取消请求
+func (s *Student) GetNoticeInfoCtx(ctx context.Context, req *NoticeInfoReq) (list []*NoticeInfo, totalPages int, err error) {
+	ctx = ContextWithOperation(ctx, "GetNoticeInfo")
+	defer s.finish(ctx, time.Now(), &err)
+	page, err := cached(ctx, s, []string{strconv.Itoa(req.PageNum)}, func(ctx context.Context) (noticePage, error) {
+		list, totalPages, err := s.getNoticeInfo(ctx, req)
+		return noticePage{List: list, TotalPages: totalPages}, err
+	})
+	return page.List, page.TotalPages, err
+}
+
+// noticePage GetNoticeInfo 的一页结果，用于缓存
+type noticePage struct {
+	List       []*NoticeInfo `json:"list"`
+	TotalPages int           `json:"total_pages"`
+}
+
+func (s *Student) getNoticeInfo(ctx context.Context, req *NoticeInfoReq) (list []*NoticeInfo, totalPages int, err error) {
 	// 获取通知公告页面的总页数
-	res, err := s.NewRequest().
-		SetHeader("User-Agent", constants.UserAgent).
-		Get(constants.NoticeInfoQueryURL)
+	res, err := s.NewRequest().SetContext(ctx).
+		SetHeader("User-Agent", s.userAgent()).
+		Get(s.endpoints.NoticeInfoQueryURL)
 	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, 0, ctxErr
+		}
+		return nil, 0, networkError(err)
+	}
+	if err = statusError(res); err != nil {
 		return nil, 0, err
 	}
 
 	doc, err := htmlquery.Parse(strings.NewReader(string(res.Body())))
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, errno.HTMLParseError.WithErr(err)
 	}
 
+	p := s.parser()
+
 	// 获取总页数
-	lastPageNum, err := getTotalPages(doc)
+	lastPageNum, err := p.NoticeTotalPages(doc)
 	if err != nil {
 		return nil, 0, err
 	}
 	// 判断是否超出总页数
 	if req.PageNum > lastPageNum {
-		return nil, lastPageNum, fmt.Errorf("超出总页数")
+		return nil, lastPageNum, errno.ParamError.WithMessage("超出总页数")
 	}
 	// 首页直接爬取
 	if req.PageNum == 1 {
-		list, err = parseNoticeInfo(doc)
+		list, err = p.NoticeList(doc, s.endpoints.NoticeURLPrefix)
 		if err != nil {
 			return nil, lastPageNum, err
 		}
@@ -61,19 +90,25 @@ func (s *Student) GetNoticeInfo(req *NoticeInfoReq) (list []*NoticeInfo, totalPa
 	}
 	// 根据总页数计算 url
 	num := lastPageNum - req.PageNum + 1
-	url := fmt.Sprintf("https://jwch.fzu.edu.cn/jxtz/%d.htm", num)
-	resp, err := s.NewRequest().
-		SetHeader("User-Agent", constants.UserAgent).
+	url := fmt.Sprintf("%sjxtz/%d.htm", s.endpoints.NoticeURLPrefix, num)
+	resp, err := s.NewRequest().SetContext(ctx).
+		SetHeader("User-Agent", s.userAgent()).
 		Get(url)
 	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, lastPageNum, ctxErr
+		}
+		return nil, lastPageNum, networkError(err)
+	}
+	if err = statusError(resp); err != nil {
 		return nil, lastPageNum, err
 	}
 
 	doc, err = htmlquery.Parse(strings.NewReader(string(resp.Body())))
 	if err != nil {
-		return nil, lastPageNum, err
+		return nil, lastPageNum, errno.HTMLParseError.WithErr(err)
 	}
-	list, err = parseNoticeInfo(doc)
+	list, err = p.NoticeList(doc, s.endpoints.NoticeURLPrefix)
 	if err != nil {
 		return nil, lastPageNum, err
 	}
@@ -81,158 +116,47 @@ func (s *Student) GetNoticeInfo(req *NoticeInfoReq) (list []*NoticeInfo, totalPa
 	return list, lastPageNum, nil
 }
 
-// 获取当前页面的所有数据信息
-func parseNoticeInfo(doc *html.Node) ([]*NoticeInfo, error) {
-	// 解析通知公告页面
-	var list []*NoticeInfo
-
-	sel := htmlquery.FindOne(doc, "//div[@class='box-gl clearfix']")
-	if sel == nil {
-		return nil, fmt.Errorf("cannot find the notice list")
-	}
-
-	rows := htmlquery.Find(sel, ".//ul[@class='list-gl']/li")
-
-	for _, row := range rows {
-		// 提取日期
-		dateNode := htmlquery.FindOne(row, ".//span[@class='doclist_time']")
-		if dateNode == nil {
-			return nil, fmt.Errorf("cannot find the date")
-		}
-		date := strings.TrimSpace(htmlquery.InnerText(dateNode))
-
-		// 提取标题
-		titleNode := htmlquery.FindOne(row, ".//a")
-
-		title := strings.TrimSpace(htmlquery.SelectAttr(titleNode, "title"))
-
-		// 提取 URL
-		rawURL := strings.TrimSpace(htmlquery.SelectAttr(titleNode, "href"))
-		rawURL = constants.JwchNoticeURLPrefix + rawURL
-
-		convertedURL, wbTreeId, wbNewsId := convertURL(rawURL)
-
-		noticeInfo := &NoticeInfo{
-			Title:    title,
-			URL:      convertedURL,
-			Date:     date,
-			WbTreeId: wbTreeId,
-			WbNewsId: wbNewsId,
-		}
-		list = append(list, noticeInfo)
-	}
-
-	return list, nil
-}
-
-// 获取总页数
-func getTotalPages(doc *html.Node) (int, error) {
-	totalPagesNode := htmlquery.FindOne(doc, "//span[@class='p_pages']//a[@href='jxtz/1.htm']")
-	if totalPagesNode == nil {
-		return 0, fmt.Errorf("未找到总页数")
-	}
-
-	totalPagesStr := htmlquery.InnerText(totalPagesNode)
-	var totalPages int
-	_, err := fmt.Sscanf(totalPagesStr, "%d", &totalPages)
-	if err != nil {
-		return 0, fmt.Errorf("解析总页数失败: %v", err)
-	}
-	return totalPages, nil
+// GetNoticeDetail 获取通知正文内容
+func (s *Student) GetNoticeDetail(req *NoticeDetailReq) (*NoticeDetail, error) {
+	return s.GetNoticeDetailCtx(context.Background(), req)
 }
 
-// 将通知公告列表中的 URL 转换成 content.jsp 格式，并提取 wbtreeid 和 wbnewsid
-//
-// 例：将
-//   - https://jwch.fzu.edu.cn/../info/1040/13769.htm
-//   - https://jwch.fzu.edu.cn/info/1040/13769.htm
-//   - https://jwch.fzu.edu.cn/../content.jsp?urltype=news.NewsContentUrl&wbtreeid=1040&wbnewsid=13769
-//
-// 转换成
-// - https://jwch.fzu.edu.cn/content.jsp?urltype=news.NewsContentUrl&wbtreeid=1040&wbnewsid=13769
-//
-// Returns:
-//   - finalURL
-//   - wbTreeId
-//   - wbNewsId
-func convertURL(original string) (string, string, string) {
-	// 去除 "../"
-	cleaned := strings.ReplaceAll(original, "../", "")
-
-	// 正则提取 wbtreeid 和 wbnewsid（info/TREE/NEWS.htm 格式）
-	re := regexp.MustCompile(`info/(\d+)/(\d+)\.htm`)
-	matches := re.FindStringSubmatch(cleaned)
-	if len(matches) == 3 {
-		wbtreeid := matches[1]
-		wbnewsid := matches[2]
-		newURL := fmt.Sprintf("https://jwch.fzu.edu.cn/content.jsp?urltype=news.NewsContentUrl&wbtreeid=%s&wbnewsid=%s", wbtreeid, wbnewsid)
-		return newURL, wbtreeid, wbnewsid
-	}
-
-	// 已经是 content.jsp 格式，从 query string 中提取 wbtreeid 和 wbnewsid
-	parsed, err := url.Parse(cleaned)
-	if err == nil {
-		q := parsed.Query()
-		wbtreeid := q.Get("wbtreeid")
-		wbnewsid := q.Get("wbnewsid")
-		if wbtreeid != "" && wbnewsid != "" {
-			return cleaned, wbtreeid, wbnewsid
-		}
-	}
-
-	return cleaned, "", ""
+// GetNoticeDetailCtx 同 GetNoticeDetail，支持通过 ctx 取消请求
+func (s *Student) GetNoticeDetailCtx(ctx context.Context, req *NoticeDetailReq) (_ *NoticeDetail, err error) {
+	ctx = ContextWithOperation(ctx, "GetNoticeDetail")
+	defer s.finish(ctx, time.Now(), &err)
+	return cached(ctx, s, []string{req.WbTreeId, req.WbNewsId}, func(ctx context.Context) (*NoticeDetail, error) {
+		return s.getNoticeDetail(ctx, req)
+	})
 }
 
-// GetNoticeDetail 获取通知正文内容
-func (s *Student) GetNoticeDetail(req *NoticeDetailReq) (*NoticeDetail, error) {
-	targetURL := fmt.Sprintf("https://jwch.fzu.edu.cn/content.jsp?urltype=news.NewsContentUrl&wbtreeid=%s&wbnewsid=%s", req.WbTreeId, req.WbNewsId)
+func (s *Student) getNoticeDetail(ctx context.Context, req *NoticeDetailReq) (*NoticeDetail, error) {
+	targetURL := fmt.Sprintf("%scontent.jsp?urltype=news.NewsContentUrl&wbtreeid=%s&wbnewsid=%s", s.endpoints.NoticeURLPrefix, req.WbTreeId, req.WbNewsId)
 
-	res, err := s.NewRequest().
-		SetHeader("User-Agent", constants.UserAgent).
+	res, err := s.NewRequest().SetContext(ctx).
+		SetHeader("User-Agent", s.userAgent()).
 		Get(targetURL)
 	if err != nil {
-		return nil, fmt.Errorf("GetNoticeDetail: failed to fetch url %s: %w", targetURL, err)
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, networkError(err)
+	}
+	if err = statusError(res); err != nil {
+		return nil, err
 	}
 
 	doc, err := htmlquery.Parse(strings.NewReader(string(res.Body())))
 	if err != nil {
-		return nil, fmt.Errorf("GetNoticeDetail: failed to parse html: %w", err)
-	}
-
-	// 主容器
-	mainNode := htmlquery.FindOne(doc, "//div[contains(@class,'xl_main')]")
-	if mainNode == nil {
-		return nil, fmt.Errorf("GetNoticeDetail: .xl_main not found, url=%s", targetURL)
+		return nil, errno.HTMLParseError.WithErr(err)
 	}
 
-	// 提取标题
-	titleNode := htmlquery.FindOne(mainNode, ".//*[contains(@class,'xl_tit')]/h4")
-	if titleNode == nil {
-		return nil, fmt.Errorf("GetNoticeDetail: .xl_tit h4 not found, url=%s", targetURL)
-	}
-	title := strings.TrimSpace(htmlquery.InnerText(titleNode))
-
-	// 提取发布时间
-	timeNode := htmlquery.FindOne(mainNode, ".//*[contains(@class,'xl_sj')]//span[1]")
-	if timeNode == nil {
-		return nil, fmt.Errorf("GetNoticeDetail: .xl_sj span not found, url=%s", targetURL)
-	}
-	date := strings.TrimPrefix(strings.TrimSpace(htmlquery.InnerText(timeNode)), "发布时间：")
-
-	// 提取内容
-	contentNode := htmlquery.FindOne(mainNode, ".//*[@id='vsb_content']")
-	if contentNode == nil {
-		return nil, fmt.Errorf("GetNoticeDetail: #vsb_content not found, url=%s", targetURL)
+	detail, err := s.parser().NoticeDetail(doc)
+	if err != nil {
+		return nil, withURL(err, targetURL)
 	}
-
-	return &NoticeDetail{
-		NoticeInfo: NoticeInfo{
-			Title:    title,
-			Date:     date,
-			URL:      targetURL,
-			WbTreeId: req.WbTreeId,
-			WbNewsId: req.WbNewsId,
-		},
-		Content: htmlquery.InnerText(contentNode),
-	}, nil
+	detail.URL = targetURL
+	detail.WbTreeId = req.WbTreeId
+	detail.WbNewsId = req.WbNewsId
+	return detail, nil
 }
@@ -0,0 +1,273 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/west2-online/jwch/selectors"
+)
+
+// Option 创建 Student 时的可选配置
+type Option func(o *options)
+
+type options struct {
+	httpClient    *http.Client
+	transport     http.RoundTripper
+	timeout       time.Duration
+	tlsConfig     *tls.Config
+	config        *Config
+	configSet     bool // 是否显式指定了 config，未指定时从环境变量加载
+	proxyURL      *url.URL
+	userAgent     string
+	logger        *slog.Logger
+	captchaSolver CaptchaSolver
+	endpoints     *Endpoints
+	selectors     *selectors.Registry
+	autoRelogin   bool
+	limiter       *Limiter
+	retryPolicy   *RetryPolicy
+	proxyPool     *ProxyPool
+	hooks         []Hook
+	proxyRotation ProxyRotation
+	fanOut        int
+	fanOutSet     bool
+	fanOutObs     FanOutObserver
+	metrics       MetricsCollector
+	cache         *studentCache
+	recorder      *Recorder
+}
+
+// WithHTTPClient 使用指定的 http.Client 发起请求，client 本身不会被修改
+// 注意：为了完成登录流程，重定向策略会被覆盖为不跟随重定向；client 的 Jar 不会被使用，每个 Student 有自己的 cookie jar
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithTransport 使用指定的 RoundTripper 发起请求，此时 WithTLSConfig、WithConfig 和 WithProxyURL 不再生效
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithTimeout 设置单次请求的超时时间，默认不超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithTLSConfig 设置 TLS 配置，默认跳过证书校验（教务处的证书链不完整）
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithConfig 使用指定的配置（主要是青果网络代理），传入 nil 表示不使用代理
+// 不指定时与之前一样从环境变量加载，见 LoadConfigFromEnv
+func WithConfig(config *Config) Option {
+	return func(o *options) {
+		o.config = config
+		o.configSet = true
+	}
+}
+
+// WithProxyURL 使用固定的 HTTP 代理，优先级高于 WithProxyPool 和 WithConfig
+func WithProxyURL(proxyURL *url.URL) Option {
+	return func(o *options) {
+		o.proxyURL = proxyURL
+	}
+}
+
+// WithProxyPool 从代理池中选择代理，rotation 指定每个请求还是每个 Student 切换代理，优先级高于 WithConfig
+// 请求失败的代理会被反馈给代理池，连续失败后被剔除
+func WithProxyPool(pool *ProxyPool, rotation ProxyRotation) Option {
+	return func(o *options) {
+		o.proxyPool = pool
+		o.proxyRotation = rotation
+	}
+}
+
+// WithUserAgent 设置请求携带的 User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithLogger 设置日志输出，默认使用 slog.Default()
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithCaptchaSolver 设置验证码识别器，等同于 (*Student).WithCaptchaSolver
+func WithCaptchaSolver(solver CaptchaSolver) Option {
+	return func(o *options) {
+		o.captchaSolver = solver
+	}
+}
+
+// WithEndpoints 设置教务处各接口的地址，等同于 (*Student).WithEndpoints
+func WithEndpoints(endpoints Endpoints) Option {
+	return func(o *options) {
+		o.endpoints = &endpoints
+	}
+}
+
+// WithSelectors 设置解析页面使用的选择器，等同于 (*Student).WithSelectors
+func WithSelectors(registry *selectors.Registry) Option {
+	return func(o *options) {
+		o.selectors = registry
+	}
+}
+
+// WithAutoRelogin 设置会话过期时是否自动重新登录，等同于 (*Student).WithAutoRelogin
+func WithAutoRelogin(enabled bool) Option {
+	return func(o *options) {
+		o.autoRelogin = enabled
+	}
+}
+
+// WithLimiter 使用指定的限流器限制所有发往教务处的请求，同一个 Limiter 可以在多个 Student 之间共享
+func WithLimiter(limiter *Limiter) Option {
+	return func(o *options) {
+		o.limiter = limiter
+	}
+}
+
+// WithRetryPolicy 设置网络错误时的重试策略，默认不重试，见 DefaultRetryPolicy
+// 只有 GET 等安全的请求（包括回发前获取 VIEWSTATE 的请求）会被重试，POST 提交不会被重试
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = &policy
+	}
+}
+
+// WithHooks 添加请求的回调，等同于 (*Student).WithHooks
+func WithHooks(hooks ...Hook) Option {
+	return func(o *options) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
+
+// WithMetrics 记录每次公开操作的耗时和结果，等同于 (*Student).WithMetrics
+func WithMetrics(collector MetricsCollector) Option {
+	return func(o *options) {
+		o.metrics = collector
+	}
+}
+
+// WithCache 缓存校历、通知、空教室等结果，等同于 (*Student).WithCache
+func WithCache(cache Cache, policies map[string]CachePolicy) Option {
+	return func(o *options) {
+		o.cache = nil
+		if cache != nil {
+			o.cache = newStudentCache(cache, policies)
+		}
+	}
+}
+
+// WithRecorder 记录每次实际发出的请求和响应，用于复现解析错误，见 Recorder 和 ReplayTransport
+func WithRecorder(recorder *Recorder) Option {
+	return func(o *options) {
+		o.recorder = recorder
+	}
+}
+
+// WithFanOutConcurrency 设置 GetEmptyRoom 等并发查询的最大 worker 数，等同于 (*Student).WithFanOutConcurrency
+func WithFanOutConcurrency(n int) Option {
+	return func(o *options) {
+		o.fanOut = n
+		o.fanOutSet = true
+	}
+}
+
+// WithFanOutObserver 设置并发查询结束时的回调，可用于统计排队时长，等同于 (*Student).WithFanOutObserver
+func WithFanOutObserver(observer FanOutObserver) Option {
+	return func(o *options) {
+		o.fanOutObs = observer
+	}
+}
+
+// newTransport 根据配置创建默认的 Transport，proxied 为 true 时使用 proxyTransport 为每个请求选择的代理
+func (o *options) newTransport(proxied bool) *http.Transport {
+	tlsConfig := o.tlsConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	// Disable HTTP/2.0
+	transport := &http.Transport{
+		TLSNextProto:    make(map[string]func(authority string, c *tls.Conn) http.RoundTripper),
+		TLSClientConfig: tlsConfig,
+	}
+
+	switch {
+	case o.proxyURL != nil:
+		transport.Proxy = http.ProxyURL(o.proxyURL)
+	case proxied:
+		// 代理由 proxyTransport 为每个请求选择后通过 ctx 传入
+		transport.Proxy = proxyFromContext
+	}
+	return transport
+}
+
+// proxySource 默认 Transport 使用的代理池
+type proxySource struct {
+	pool     *ProxyPool
+	rotation ProxyRotation
+	direct   bool // 代理池没有可用的代理时是否直连
+}
+
+// resolveProxy 确定默认 Transport 使用的代理池：WithProxyURL 优先，其次是 WithProxyPool，
+// 最后是启用了青果网络代理的配置（未指定 WithConfig 时从环境变量加载）共享的代理池，每个 Student 固定使用一条隧道
+// 与之前一样，青果网络的隧道查询失败时直连，WithProxyPool 指定的代理池不可用时请求失败
+func (o *options) resolveProxy() proxySource {
+	if o.proxyURL != nil {
+		return proxySource{}
+	}
+	if o.proxyPool != nil {
+		return proxySource{pool: o.proxyPool, rotation: o.proxyRotation}
+	}
+
+	config := o.config
+	if !o.configSet {
+		config = LoadConfigFromEnv()
+	}
+	if config != nil && config.Proxy.Enabled && config.Proxy.AuthKey != "" && config.Proxy.AuthPwd != "" {
+		return proxySource{pool: config.ProxyPool(), rotation: ProxyPerStudent, direct: true}
+	}
+	return proxySource{}
+}
+
+// wrap 在默认 Transport 外包装代理池的选择逻辑，使用自定义 Transport 时不生效
+func (p proxySource) wrap(next http.RoundTripper, logger *slog.Logger) http.RoundTripper {
+	if p.pool == nil {
+		return next
+	}
+	return &proxyTransport{pool: p.pool, next: next, rotation: p.rotation, direct: p.direct, logger: logger}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// curTermRegexp 匹配校历页面中的当前学期，例如 当前学期：202401
+var curTermRegexp = regexp.MustCompile(`当前学期：(\d{6})`)
+
+// SchoolCalendar 解析校历页面的当前学期和学期列表，只保留最近的 16 个学期
+func (p *Parser) SchoolCalendar(doc *html.Node) (*model.SchoolCalendar, error) {
+	curTermNode := htmlquery.FindOne(doc, p.sel.XPath(selectors.CalendarTerm))
+	if curTermNode == nil {
+		return nil, errno.HTMLParseError.WithMessage("failed to find current term node")
+	}
+
+	curTermMatch := curTermRegexp.FindStringSubmatch(htmlquery.InnerText(curTermNode))
+	if len(curTermMatch) < 2 {
+		return nil, errno.HTMLParseError.WithMessage("failed to parse current term from school calendar page")
+	}
+
+	res := &model.SchoolCalendar{
+		CurrentTerm: curTermMatch[1],
+	}
+
+	list := htmlquery.Find(doc, p.sel.XPath(selectors.CalendarTerms))
+
+	for _, node := range list {
+		// 需要取前16个年份
+		if len(res.Terms) >= 16 {
+			break
+		}
+		rawTerm := htmlquery.SelectAttr(node, "value")
+		if len(rawTerm) < 22 {
+			continue
+		}
+		/*
+			2024012024082620250117
+			[0] 202401
+			[1] 20240826
+			[2] 20250117
+		*/
+		schoolYear := rawTerm[0:4]
+		term := rawTerm[0:6]
+		startDate := rawTerm[6:14]
+		endDate := rawTerm[14:22]
+
+		// convert 20240826 to 2024-08-26
+		startDate = startDate[0:4] + "-" + startDate[4:6] + "-" + startDate[6:8]
+		endDate = endDate[0:4] + "-" + endDate[4:6] + "-" + endDate[6:8]
+
+		res.Terms = append(res.Terms, model.CalTerm{
+			TermId:     rawTerm,
+			SchoolYear: schoolYear,
+			Term:       term,
+			StartDate:  startDate,
+			EndDate:    endDate,
+		})
+	}
+
+	return res, nil
+}
+
+// TermEvents 解析校历中 termId 学期的安排，termId 为校历学期下拉框的值，例如 2024012024082620250117
+func (p *Parser) TermEvents(doc *html.Node, termId string) (*model.CalTermEvents, error) {
+	if len(termId) < 6 {
+		return nil, errno.ParamError.WithMessage("invalid term id: " + termId)
+	}
+	res := &model.CalTermEvents{
+		TermId:     termId,
+		Term:       termId[0:6],
+		SchoolYear: termId[0:4],
+	}
+	// 远古校历没有任何内容
+	table := htmlquery.FindOne(doc, p.sel.XPath(selectors.CalendarEvents))
+	if table == nil {
+		return res, nil
+	}
+	rawTermDetail := htmlquery.InnerText(table)
+	rawTermDetail = strings.ReplaceAll(rawTermDetail, " ", " ")
+
+	termDetail := strings.Split(rawTermDetail, "；")
+
+	for _, event := range termDetail {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+
+		rawData := strings.Split(event, "为")
+		if len(rawData) < 2 {
+			// 远古学期的数据格式可能不统一，不做处理
+			res.Events = append(res.Events, model.CalTermEvent{Name: strings.TrimSpace(event)})
+			continue
+		}
+
+		rawDate := strings.Split(strings.TrimSpace(rawData[0]), "至")
+		name := strings.TrimSpace(strings.Join(rawData[1:], "为"))
+
+		if len(rawDate) >= 2 {
+			startDate := strings.TrimSpace(rawDate[0])
+			endDate := strings.TrimSpace(rawDate[1])
+
+			res.Events = append(res.Events, model.CalTermEvent{
+				Name:      name,
+				StartDate: startDate,
+				EndDate:   endDate,
+			})
+		} else {
+			// 兼容单日事件格式: 2025-09-07为学生注册
+			date := strings.TrimSpace(rawDate[0])
+			res.Events = append(res.Events, model.CalTermEvent{
+				Name:      name,
+				StartDate: date,
+				EndDate:   date,
+			})
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/parse"
+)
+
+func TestParseTermEventsCharset(t *testing.T) {
+	const termId = "2025012025090120260116"
+	expected := []model.CalTermEvent{
+		{Name: "新生入学教育", StartDate: "2025-09-01", EndDate: "2025-09-05"},
+		{Name: "学生注册", StartDate: "2025-09-07", EndDate: "2025-09-07"},
+		{Name: "国庆节放假", StartDate: "2025-10-01", EndDate: "2025-10-08"},
+	}
+
+	gb := readFixture(t, "term_events")
+	utf8Page, err := simplifiedchinese.GB18030.NewDecoder().Bytes(gb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages := map[string][]byte{
+		"meta gb2312": gb,
+		"no meta":     bytes.Replace(gb, []byte(`<meta http-equiv="Content-Type" content="text/html; charset=gb2312">`), nil, 1),
+		"utf-8":       bytes.Replace(utf8Page, []byte("charset=gb2312"), []byte("charset=utf-8"), 1),
+	}
+	for name, page := range pages {
+		events, err := parse.ParseTermEvents(bytes.NewReader(page), termId)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if events.Term != "202501" || !reflect.DeepEqual(events.Events, expected) {
+			t.Errorf("%s: unexpected events: %+v", name, events)
+		}
+	}
+
+	// Content-Type 中声明的编码优先于 meta 标签，这里 meta 标签仍然是 gb2312
+	doc, err := parse.Document(bytes.NewReader(utf8Page), "text/html; charset=utf-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events, err := parse.New(nil).TermEvents(doc, termId); err != nil || len(events.Events) != 3 {
+		t.Errorf("unexpected events: %+v, %v", events, err)
+	}
+
+	if _, err := parse.ParseTermEvents(bytes.NewReader(gb), "2025"); !errors.Is(err, errno.ParamError) {
+		t.Errorf("expected ParamError, got %v", err)
+	}
+}
+
+func TestParseSchoolCalendar(t *testing.T) {
+	calendar, err := parse.ParseSchoolCalendar(bytes.NewReader(readFixture(t, "school_calendar")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calendar.CurrentTerm != "202501" || len(calendar.Terms) != 3 {
+		t.Fatalf("unexpected calendar: %+v", calendar)
+	}
+	if calendar.Terms[2] != (model.CalTerm{TermId: "2024012024082620250117", SchoolYear: "2024", Term: "202401", StartDate: "2024-08-26", EndDate: "2025-01-17"}) {
+		t.Errorf("unexpected term: %+v", calendar.Terms[2])
+	}
+
+	if _, err := parse.ParseSchoolCalendar(bytes.NewReader([]byte("<html></html>"))); !errors.Is(err, errno.HTMLParseError) {
+		t.Errorf("expected HTMLParseError, got %v", err)
+	}
+}
+
+func TestParseLocateDate(t *testing.T) {
+	date, err := parse.ParseLocateDate(bytes.NewReader(readFixture(t, "locate_date")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *date != (model.LocateDate{Week: "5", Year: "2025", Term: "01"}) {
+		t.Errorf("unexpected date: %+v", date)
+	}
+
+	if _, err := parse.LocateDate(`var week = "5";`); !errors.Is(err, errno.HTMLParseError) {
+		t.Errorf("expected HTMLParseError, got %v", err)
+	}
+}
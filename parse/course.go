@@ -0,0 +1,271 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+	"github.com/west2-online/jwch/utils"
+)
+
+// Terms 解析选课页面的学期列表，以及查询选课结果时需要带回的 VIEWSTATE 和 EVENTVALIDATION
+func (p *Parser) Terms(doc *html.Node) (*model.Term, error) {
+	state := p.ViewState(doc)
+	res := &model.Term{
+		ViewState:       state["VIEWSTATE"],
+		EventValidation: state["EVENTVALIDATION"],
+	}
+
+	// 获取学年学期，例如 202202/202201/202102/202101 需要获取value
+	list := htmlquery.Find(doc, p.sel.XPath(selectors.CourseTerms))
+
+	// 这里考虑过使用 len(list) < 1，但是实际上这没必要，因为小于1那么它必定是0
+	if len(list) == 0 {
+		return nil, errno.HTMLParseError.WithMessage("empty terms")
+	}
+
+	for _, node := range list {
+		res.Terms = append(res.Terms, htmlquery.SelectAttr(node, "value"))
+	}
+
+	return res, nil
+}
+
+// Courses 解析选课结果页面，课程大纲和授课计划的链接以 prefix 补全，通常为教务处的地址
+func (p *Parser) Courses(doc *html.Node, prefix string) ([]*model.Course, error) {
+	table := htmlquery.FindOne(doc, p.sel.XPath(selectors.CourseTable))
+	if table == nil {
+		return nil, errno.HTMLParseError.WithMessage("get course table failed")
+	}
+	// 第一行是标题栏，第二行是表头，按表头文字定位各列
+	courses, err := newHTMLTable(selectors.Courses, htmlquery.Find(table, "tr"), p.sel.Columns(selectors.Courses))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := courses.dataRows()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*model.Course, 0, len(rows))
+
+	for _, row := range rows {
+		// 解析调课信息
+		// 第二个理论上来说是一个不标准的调课信息，但是不知道为什么被录入了教务系统导致炸掉，所以修改了一下解析的正则表达式来做了兼容。 -- @renbaoshuo
+		/*
+			06周 星期3:5-6节  调至  09周 星期1:7-8节  旗山西1-206
+			4 周 星期2:3-4节  调至  05周 星期2:7-8节  旗山东3-101
+		*/
+		adjustInfo := strings.Split(utils.InnerTextWithBr(row.cell("adjust")), "\n")
+		// 注意：下面的正则里面有 NO-BREAK SPACE (U+00A0 %C2%A0)
+		adjustRegex := regexp.MustCompile(`(\d{1,2})[\s ]*周[\s ]*星期(\d):(\d{1,2})-(\d{1,2})节[\s ]*调至[\s ]*(\d{1,2})[\s ]*周[\s ]*星期(\d):(\d{1,2})-(\d{1,2})节[\s ]*(\S*)`)
+		adjustRules := []model.CourseAdjustRule{}
+
+		for i := 0; i < len(adjustInfo); i++ {
+			adjustInfo[i] = strings.TrimSpace(adjustInfo[i])
+
+			if adjustInfo[i] == "" { // 空行
+				continue
+			}
+
+			adjustMatchArr := adjustRegex.FindStringSubmatch(adjustInfo[i])
+
+			if len(adjustMatchArr) < 10 {
+				return nil, errno.HTMLParseError.WithMessage("get course adjust failed")
+			}
+
+			adjustRules = append(adjustRules, model.CourseAdjustRule{
+				OldWeek:       utils.SafeAtoi(adjustMatchArr[1]),
+				OldWeekday:    utils.SafeAtoi(adjustMatchArr[2]),
+				OldStartClass: utils.SafeAtoi(adjustMatchArr[3]),
+				OldEndClass:   utils.SafeAtoi(adjustMatchArr[4]),
+
+				NewWeek:       utils.SafeAtoi(adjustMatchArr[5]),
+				NewWeekday:    utils.SafeAtoi(adjustMatchArr[6]),
+				NewStartClass: utils.SafeAtoi(adjustMatchArr[7]),
+				NewEndClass:   utils.SafeAtoi(adjustMatchArr[8]),
+				NewLocation:   adjustMatchArr[9],
+			})
+		}
+
+		// 解析上课时间、地点，融合调课信息
+		/*
+			05-18 星期1:3-4节 铜盘A110
+			05-17 星期3:1-2节 铜盘A110
+			05-17 星期5:3-4节 铜盘A110
+		*/
+		scheduleInfo := strings.Split(utils.InnerTextWithBr(row.cell("schedule")), "\n")
+		scheduleRules := []model.CourseScheduleRule{}
+		fullWeekScheduleRules := []model.CourseFullWeekScheduleRule{}
+
+		for i := 0; i < len(scheduleInfo); i++ {
+			scheduleInfo[i] = strings.TrimSpace(scheduleInfo[i])
+
+			if scheduleInfo[i] == "" { // 空行
+				continue
+			}
+
+			lineData := strings.Fields(scheduleInfo[i])
+
+			if len(lineData) < 3 {
+				return nil, errno.HTMLParseError.WithMessage("get course info failed")
+			}
+
+			if strings.Contains(lineData[0], "周") { // 处理整周的课程，比如军训
+				/*
+					03周  星期1  -  04周  星期7
+					[0] 03周
+					[1] 星期1
+					[2] -
+					[3] 04周
+					[4] 星期7
+				*/
+				if len(lineData) < 5 {
+					return nil, errno.HTMLParseError.WithMessage("get course full week schedule failed")
+				}
+				startWeek, _ := strconv.Atoi(strings.TrimSuffix(lineData[0], "周"))
+				endWeek, _ := strconv.Atoi(strings.TrimSuffix(lineData[3], "周"))
+				startWeekday, _ := strconv.Atoi(strings.TrimPrefix(lineData[1], "星期"))
+				endWeekday, _ := strconv.Atoi(strings.TrimPrefix(lineData[4], "星期"))
+
+				// 向普通课程的格式转换（应用于课表显示）
+				for weekday := 1; weekday <= 7; weekday++ {
+					curStartWeek := startWeek
+					curEndWeek := endWeek
+
+					if weekday < startWeekday {
+						curStartWeek++
+					}
+
+					if weekday > endWeekday {
+						curEndWeek--
+					}
+
+					if curStartWeek > curEndWeek {
+						continue
+					}
+
+					scheduleRules = append(scheduleRules, model.CourseScheduleRule{
+						Location:     "",
+						StartClass:   1,
+						EndClass:     8,
+						StartWeek:    curStartWeek,
+						EndWeek:      curEndWeek,
+						Weekday:      weekday,
+						Single:       true,
+						Double:       true,
+						Adjust:       false,
+						FromFullWeek: true,
+					})
+				}
+
+				// 记录整周课程的信息（应用于日历生成）
+				fullWeekScheduleRules = append(fullWeekScheduleRules, model.CourseFullWeekScheduleRule{
+					StartWeek:    startWeek,
+					StartWeekDay: startWeekday,
+					EndWeek:      endWeek,
+					EndWeekDay:   endWeekday,
+				})
+			} else { // 处理周内的正常课程
+				/*
+					08-16 星期5:7-8节 铜盘A508
+					[0] 08-16
+					[1] 星期5:7-8节
+					[2] 铜盘A508
+				*/
+				/*
+					02-14 星期1:1-2节(双) 旗山西1-206
+					[0] 02-14
+					[1] 星期1:1-2节(双)
+					[2] 旗山西1-206
+				*/
+				/*
+					01-13 星期1:3-4节(单) 旗山西1-206
+					[0] 01-13
+					[1] 星期1:3-4节(单)
+					[2] 旗山西1-206
+				*/
+
+				// 是不是用正则表达式更好一点？
+				weekInfo := strings.SplitN(lineData[0], "-", 2) // [8, 16]
+				dayInfo := strings.SplitN(lineData[1], ":", 2)  // ["星期5", "7-8节"] or ["星期1", "1-2节(双)"]
+				if len(weekInfo) < 2 || len(dayInfo) < 2 {
+					return nil, errno.HTMLParseError.WithMessage("get course schedule failed")
+				}
+				classBasicInfo := strings.SplitN(dayInfo[1], "节", 2)   // ["7-8", ""] or ["1-2", "(双)"]
+				classInfo := strings.SplitN(classBasicInfo[0], "-", 2) // ["7", "8"]
+				if len(classBasicInfo) < 2 || len(classInfo) < 2 {
+					return nil, errno.HTMLParseError.WithMessage("get course schedule failed")
+				}
+				location := lineData[2]
+				startClass := utils.SafeAtoi(classInfo[0])
+				endClass := utils.SafeAtoi(classInfo[1])
+				startWeek := utils.SafeAtoi(weekInfo[0])
+				endWeek := utils.SafeAtoi(weekInfo[1])
+				weekDay := utils.SafeAtoi(strings.TrimPrefix(dayInfo[0], "星期"))
+				single := !strings.Contains(classBasicInfo[1], "双")
+				double := !strings.Contains(classBasicInfo[1], "单")
+
+				scheduleRules = append(scheduleRules, model.CourseScheduleRule{
+					Location:     location,
+					StartClass:   startClass,
+					EndClass:     endClass,
+					StartWeek:    startWeek,
+					EndWeek:      endWeek,
+					Weekday:      weekDay,
+					Single:       single,
+					Double:       double,
+					Adjust:       false,
+					FromFullWeek: false,
+				})
+			}
+		}
+
+		examTime := row.text("exam_time")
+
+		// TODO: performance optimization
+		res = append(res, &model.Course{
+			Type:       htmlquery.OutputHTML(row.cell("type"), false),
+			Name:       htmlquery.OutputHTML(row.cell("name"), false),
+			Syllabus:   prefix + safeExtractRegex(`javascript:pop1\('(.*?)&`, safeExtractionValue(row.cell("links"), p.sel.XPath(selectors.CourseLinks), "href", 0)),
+			LessonPlan: prefix + safeExtractRegex(`javascript:pop1\('(.*?)&`, safeExtractionValue(row.cell("links"), p.sel.XPath(selectors.CourseLinks), "href", 1)),
+			// PaymentStatus: safeExtractionFirst(row.cell("payment_status"), "font"),
+			Credits:               safeExtractionFirst(row.cell("credits"), p.sel.XPath(selectors.CourseCredits)),
+			ElectiveType:          utils.GetChineseCharacter(htmlquery.OutputHTML(row.cell("elective_type"), false)),
+			ExamType:              utils.GetChineseCharacter(htmlquery.OutputHTML(row.cell("exam_type"), false)),
+			Teacher:               htmlquery.OutputHTML(row.cell("teacher"), false),
+			ScheduleRules:         scheduleRules,
+			AdjustRules:           adjustRules,
+			FullWeekScheduleRules: fullWeekScheduleRules,
+			RawScheduleRules:      strings.Join(scheduleInfo, "\n"),
+			RawExamTime:           examTime,
+			Exam:                  examSchedule(examTime),
+			RawAdjust:             strings.Join(adjustInfo, "\n"),
+			Remark:                htmlquery.OutputHTML(row.cell("remark"), false),
+		})
+	}
+
+	return res, nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// Credit 解析学分统计页面，主修和辅修专业的学分合并返回
+func (p *Parser) Credit(doc *html.Node) ([]*model.CreditStatistics, error) {
+	tables, err := p.creditTables(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	creditStatistics := make([]*model.CreditStatistics, 0)
+
+	for _, table := range tables {
+		stats, err := parseCreditTable(table)
+		if err != nil {
+			return nil, err
+		}
+		creditStatistics = append(creditStatistics, stats...)
+	}
+
+	return creditStatistics, nil
+}
+
+// CreditV2 解析学分统计页面，分别返回主修和辅修专业的学分
+func (p *Parser) CreditV2(doc *html.Node) (majorCredits, minorCredits []*model.CreditStatistics, err error) {
+	tables, err := p.creditTables(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 处理主修专业和辅修专业
+	for tableIndex, table := range tables {
+		stats, err := parseCreditTable(table)
+		if err != nil {
+			return nil, nil, err
+		}
+		// 第一个表格是主修专业，第二个表格是辅修专业
+		if tableIndex == 0 {
+			majorCredits = append(majorCredits, stats...)
+		} else {
+			minorCredits = append(minorCredits, stats...)
+		}
+	}
+
+	return majorCredits, minorCredits, nil
+}
+
+// creditTables 返回学分统计中各专业的表格
+func (p *Parser) creditTables(doc *html.Node) ([]*html.Node, error) {
+	spanNode := htmlquery.FindOne(doc, p.sel.XPath(selectors.CreditStatistics))
+	if spanNode == nil {
+		return nil, errno.HTMLParseError.WithMessage("failed to find the statistics span element")
+	}
+
+	tables := htmlquery.Find(spanNode, p.sel.XPath(selectors.CreditTables))
+	if len(tables) == 0 {
+		return nil, errno.HTMLParseError.WithMessage("failed to find tables within the span element")
+	}
+	return tables[:len(tables)-1], nil // 去掉最后一个表格
+}
+
+// GPA 解析绩点排名页面，出错时返回已经解析出的部分
+func (p *Parser) GPA(doc *html.Node) (*model.GPABean, error) {
+	gpa := &model.GPABean{}
+
+	document := htmlquery.FindOne(doc, p.sel.XPath(selectors.GPATime))
+	if document == nil {
+		return gpa, errno.HTMLParseError.WithMessage("failed to find the time element")
+	}
+
+	timeText := htmlquery.InnerText(document)
+	gpa.Time = strings.TrimSpace(timeText)
+
+	table := htmlquery.FindOne(doc, p.sel.XPath(selectors.GPATable))
+	if table == nil {
+		return gpa, errno.HTMLParseError.WithMessage("failed to find the GPA table")
+	}
+
+	// 获取表头标题
+	titleRow := htmlquery.FindOne(table, p.sel.XPath(selectors.GPAHeader))
+	if titleRow == nil {
+		return gpa, errno.HTMLParseError.WithMessage("failed to find the title row in GPA table")
+	}
+
+	// 获取每个表头标题的单元格
+	tdsTitle := htmlquery.Find(titleRow, p.sel.XPath(selectors.GPAHeaderCells))
+	width := len(tdsTitle)
+	if width == 0 {
+		return gpa, errno.HTMLParseError.WithMessage("failed to find header cells in GPA table")
+	}
+
+	// 获取表格中的所有数据
+	tdsFull := htmlquery.Find(table, p.sel.XPath(selectors.GPACells))
+	if len(tdsFull) == 0 {
+		return gpa, errno.HTMLParseError.WithMessage("failed to find GPA data cells")
+	}
+
+	height := len(tdsFull)/width - 1
+
+	var data []model.GPAData
+	for h := 1; h <= height; h++ {
+		for w := 0; w < width; w++ {
+			data = append(data, model.GPAData{
+				Type:  htmlquery.InnerText(tdsTitle[w]),
+				Value: htmlquery.InnerText(tdsFull[width*h+w]),
+			})
+		}
+	}
+	gpa.Data = data
+
+	return gpa, nil
+}
+
+// parseCreditTable 解析一个学分统计表格
+// 表格是横向的：第一行是学分类别，第一列为“应获学分”和“已获学分”的两行是对应的学分
+func parseCreditTable(table *html.Node) ([]*model.CreditStatistics, error) {
+	var types, total, gain []string
+	for index, row := range htmlquery.Find(table, "//tr") {
+		var texts []string
+		for _, cell := range htmlquery.Find(row, "./td") {
+			text := htmlquery.InnerText(cell)
+			if text != "查" { // 因为表格的第三行多了一个单元格，去掉一个无用的格子它使得表格规整
+				texts = append(texts, text)
+			}
+		}
+		if index == 0 {
+			types = texts
+			continue
+		}
+		if len(texts) == 0 {
+			continue
+		}
+		switch strings.TrimSpace(texts[0]) {
+		case "应获学分":
+			total = texts
+		case "已获学分":
+			gain = texts
+		}
+	}
+	if types == nil || total == nil || gain == nil {
+		return nil, errno.HTMLParseError.WithMessage("credit table: row 应获学分 or 已获学分 not found")
+	}
+	if len(total) < len(types) || len(gain) < len(types) {
+		return nil, errno.HTMLParseError.WithMessage(
+			fmt.Sprintf("credit table: header has %d cells, but 应获学分 has %d and 已获学分 has %d", len(types), len(total), len(gain)))
+	}
+
+	res := make([]*model.CreditStatistics, 0, len(types))
+	for i := range types {
+		// 去掉个人信息的列（这列第一个单元格式空的）和“修习情况”这个无效的列
+		if strings.TrimSpace(types[i]) != "" && !strings.Contains(types[i], "情况") {
+			res = append(res, &model.CreditStatistics{
+				Type:  types[i],
+				Gain:  gain[i],
+				Total: total[i],
+			})
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+)
+
+// 考试时间地点，例如 2024年11月17日 12:30-17:30  旗山数计3-404
+var examTimeRegex = regexp.MustCompile(`^(\d{4})年(\d{1,2})月(\d{1,2})日\s*(\d{1,2}):(\d{2})\s*[-－~～至]\s*(\d{1,2}):(\d{2})\s*(.*)$`)
+
+// 地点前缀与校区的对应关系
+var campusPrefixes = []struct {
+	prefix string
+	campus string
+}{
+	{"旗山", model.CampusQiShan},
+	{"铜盘", model.CampusTongPan},
+	{"怡山", model.CampusYiShan},
+	{"厦门工艺美院", model.CampusXiaMen},
+	{"工艺美院", model.CampusXiaMen},
+	{"集美", model.CampusXiaMen},
+	{"鼓浪屿", model.CampusXiaMen},
+	{"泉港", model.CampusQuanGang},
+	{"晋江", model.CampusJinJiang},
+}
+
+// Shanghai 教务处使用的时区，见 model.Shanghai
+var Shanghai = model.Shanghai
+
+// ExamSchedule 解析教务处的考试时间地点文本
+// raw 为空（尚未安排考试）时返回 nil, nil；格式无法识别时返回 ExamTimeParseError
+func ExamSchedule(raw string) (*model.ExamSchedule, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	matches := examTimeRegex.FindStringSubmatch(raw)
+	if matches == nil {
+		return nil, errno.ExamTimeParseError.WithMessage("unrecognized exam time: " + raw)
+	}
+
+	nums := make([]int, 7)
+	for i := range nums {
+		nums[i], _ = strconv.Atoi(matches[i+1])
+	}
+	year, month, day := nums[0], time.Month(nums[1]), nums[2]
+	start, okStart := examTime(year, month, day, nums[3], nums[4])
+	end, okEnd := examTime(year, month, day, nums[5], nums[6])
+	if !okStart || !okEnd {
+		return nil, errno.ExamTimeParseError.WithMessage("invalid exam time: " + raw)
+	}
+	if end.Before(start) {
+		return nil, errno.ExamTimeParseError.WithMessage("exam ends before it starts: " + raw)
+	}
+
+	location := strings.TrimSpace(matches[8])
+	campus, building, room := SplitLocation(location)
+	return &model.ExamSchedule{
+		Start:    start,
+		End:      end,
+		Campus:   campus,
+		Building: building,
+		Room:     room,
+		Location: location,
+		Raw:      raw,
+	}, nil
+}
+
+// examTime 构造考试时间，2 月 30 日、24:00 等会被 time.Date 顺延到其他时间的值返回 false
+func examTime(year int, month time.Month, day, hour, minute int) (time.Time, bool) {
+	t := time.Date(year, month, day, hour, minute, 0, 0, Shanghai)
+	y, m, d := t.Date()
+	return t, y == year && m == month && d == day && t.Hour() == hour && t.Minute() == minute
+}
+
+// examSchedule 解析课程表和成绩中的考试时间地点，无法解析时返回 nil，调用方仍可以使用原文
+func examSchedule(raw string) *model.ExamSchedule {
+	exam, err := ExamSchedule(raw)
+	if err != nil {
+		return nil
+	}
+	return exam
+}
+
+// SplitLocation 将 旗山东3-101、铜盘A110 这样的地点拆分为校区、楼栋和教室
+// 无法识别的部分留空
+func SplitLocation(location string) (campus, building, room string) {
+	rest := location
+	for _, c := range campusPrefixes {
+		if strings.HasPrefix(rest, c.prefix) {
+			campus = c.campus
+			rest = strings.TrimPrefix(rest, c.prefix)
+			break
+		}
+	}
+	if rest == "" {
+		return campus, "", ""
+	}
+
+	if i := strings.LastIndex(rest, "-"); i >= 0 {
+		return campus, rest[:i], rest[i+1:]
+	}
+
+	// 没有分隔符时，末尾的数字是教室号
+	i := len(rest)
+	for i > 0 && rest[i-1] >= '0' && rest[i-1] <= '9' {
+		i--
+	}
+	switch i {
+	case len(rest):
+		return campus, rest, ""
+	case 0:
+		return campus, "", rest
+	}
+	return campus, rest[:i], rest[i:]
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/parse"
+)
+
+func TestParseExamSchedule(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*60*60)
+	tests := []struct {
+		raw      string
+		start    time.Time
+		end      time.Time
+		campus   string
+		building string
+		room     string
+	}{
+		{
+			raw:   "2024年11月17日 12:30-17:30  旗山数计3-404",
+			start: time.Date(2024, 11, 17, 12, 30, 0, 0, shanghai), end: time.Date(2024, 11, 17, 17, 30, 0, 0, shanghai),
+			campus: "旗山校区", building: "数计3", room: "404",
+		},
+		{
+			raw:   "2025年1月9日 8:30-10:30 铜盘A110",
+			start: time.Date(2025, 1, 9, 8, 30, 0, 0, shanghai), end: time.Date(2025, 1, 9, 10, 30, 0, 0, shanghai),
+			campus: "铜盘校区", building: "A", room: "110",
+		},
+		{
+			raw:   "2025年06月20日 14:00-16:00",
+			start: time.Date(2025, 6, 20, 14, 0, 0, 0, shanghai), end: time.Date(2025, 6, 20, 16, 0, 0, 0, shanghai),
+		},
+	}
+
+	for _, tt := range tests {
+		exam, err := parse.ExamSchedule(tt.raw)
+		if err != nil {
+			t.Errorf("%s: %v", tt.raw, err)
+			continue
+		}
+		if !exam.Start.Equal(tt.start) || !exam.End.Equal(tt.end) {
+			t.Errorf("%s: unexpected time %v - %v", tt.raw, exam.Start, exam.End)
+		}
+		if exam.Campus != tt.campus || exam.Building != tt.building || exam.Room != tt.room {
+			t.Errorf("%s: unexpected location %q %q %q", tt.raw, exam.Campus, exam.Building, exam.Room)
+		}
+		if name, _ := exam.Start.Zone(); name != "CST" {
+			t.Errorf("%s: unexpected zone %s", tt.raw, name)
+		}
+	}
+
+	if exam, err := parse.ExamSchedule("  "); exam != nil || err != nil {
+		t.Errorf("expected nil for empty input, got %v %v", exam, err)
+	}
+	for _, raw := range []string{
+		"2024年11月17日", "待定", "2024年13月17日 12:30-17:30", "2024年11月17日 17:30-12:30",
+		"2025年2月30日 08:30-10:30", "2024年11月31日 08:30-10:30", "2024年11月17日 22:00-24:00", "2024年11月17日 08:60-10:30",
+	} {
+		if _, err := parse.ExamSchedule(raw); errno.ConvertErr(err).ErrorCode != errno.ExamTimeParseError.ErrorCode {
+			t.Errorf("%s: expected ExamTimeParseError, got %v", raw, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+	"github.com/west2-online/jwch/utils"
+)
+
+// Lectures 解析已报名讲座的页面
+func (p *Parser) Lectures(doc *html.Node) ([]*model.Lecture, error) {
+	table := htmlquery.FindOne(doc, p.sel.XPath(selectors.LectureTable))
+	if table == nil {
+		return nil, errno.HTMLParseError.WithMessage("lectures table not found")
+	}
+
+	// 前三行分别为 空行 标题 表头，按表头文字定位各列
+	lectures, err := newHTMLTable(selectors.Lectures, htmlquery.Find(table, "tr"), p.sel.Columns(selectors.Lectures))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := lectures.dataRows()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*model.Lecture, 0, len(rows))
+
+	for _, row := range rows {
+		res = append(res, &model.Lecture{
+			Category:         htmlquery.InnerText(row.cell("category")),
+			IssueNumber:      utils.SafeAtoi(htmlquery.InnerText(row.cell("issue_number"))),
+			Title:            htmlquery.InnerText(row.cell("title")),
+			Speaker:          htmlquery.InnerText(row.cell("speaker")),
+			Timestamp:        parseDateTime(htmlquery.InnerText(row.cell("time"))),
+			Location:         htmlquery.InnerText(row.cell("location")),
+			AttendanceStatus: htmlquery.InnerText(row.cell("attendance_status")),
+		})
+	}
+
+	return res, nil
+}
+
+func parseDateTime(dateTime string) int64 {
+	t, err := time.ParseInLocation("2006-01-02\u00A0\u00A015：04", dateTime, Shanghai)
+	if err != nil {
+		return 0
+	}
+	return t.UnixMilli()
+}
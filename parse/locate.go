@@ -0,0 +1,36 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"regexp"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+)
+
+// locateDateRegexp 匹配当前周次页面脚本中的周次、学年和学期
+var locateDateRegexp = regexp.MustCompile(`var week = "([0-9]+)";\s*//.*\s*var xn = "([0-9]{4})";\s*//.*\s*var xq = "([0-9]{2})";`)
+
+// LocateDate 解析当前周次页面，page 为已转换为 UTF-8 的页面内容
+func LocateDate(page string) (*model.LocateDate, error) {
+	matches := locateDateRegexp.FindStringSubmatch(page)
+	if len(matches) < 4 {
+		return nil, errno.HTMLParseError.WithMessage("failed to parse response from JWCH_LOCATE_DATE_URL")
+	}
+	return &model.LocateDate{Week: matches[1], Year: matches[2], Term: matches[3]}, nil
+}
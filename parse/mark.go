@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+	"github.com/west2-online/jwch/utils"
+)
+
+// Marks 解析成绩查询页面，由于教务处缺陷，页面中包含全部的成绩
+func (p *Parser) Marks(doc *html.Node) ([]*model.Mark, error) {
+	table := htmlquery.FindOne(doc, p.sel.XPath(selectors.MarksTable))
+	if table == nil {
+		return nil, errno.HTMLParseError.WithMessage("marks table not found")
+	}
+
+	// 第一行是标题栏，第二行是表头，按表头文字定位各列
+	marks, err := newHTMLTable(selectors.Marks, htmlquery.Find(table, "tr"), p.sel.Columns(selectors.Marks))
+	if err != nil {
+		return nil, err
+	}
+	// 教务处的表格HTML是不规范的，成绩行都带有 style 属性，没有 style 的行不是成绩
+	marks.rows = slices.DeleteFunc(marks.rows, func(row *html.Node) bool {
+		return strings.TrimSpace(htmlquery.SelectAttr(row, "style")) == ""
+	})
+	rows, err := marks.dataRows()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*model.Mark, 0, len(rows))
+
+	for _, row := range rows {
+		examTime := row.text("exam_time")
+
+		res = append(res, &model.Mark{
+			Type:          htmlquery.OutputHTML(row.cell("type"), false),
+			Semester:      htmlquery.OutputHTML(row.cell("semester"), false),
+			Name:          htmlquery.OutputHTML(row.cell("name"), false),
+			Credits:       safeExtractionFirst(row.cell("credits"), p.sel.XPath(selectors.MarksCredits)),
+			Score:         safeExtractionFirst(row.cell("score"), p.sel.XPath(selectors.MarksScore)),
+			GPA:           htmlquery.OutputHTML(row.cell("gpa"), false),
+			EarnedCredits: htmlquery.OutputHTML(row.cell("earned_credits"), false),
+			ElectiveType:  utils.GetChineseCharacter(htmlquery.OutputHTML(row.cell("elective_type"), false)),
+			ExamType:      utils.GetChineseCharacter(htmlquery.OutputHTML(row.cell("exam_type"), false)),
+			Teacher:       htmlquery.OutputHTML(row.cell("teacher"), false),
+			Classroom:     row.text("classroom"),
+			ExamTime:      examTime,
+			Exam:          examSchedule(examTime),
+		})
+	}
+
+	return res, nil
+}
+
+// UnifiedExams 解析 CET、省计算机等统一考试的成绩页面，没有成绩时返回 nil, nil
+func (p *Parser) UnifiedExams(doc *html.Node) ([]*model.UnifiedExam, error) {
+	var exams []*model.UnifiedExam
+
+	// 查找包含成绩的表格
+	table := htmlquery.FindOne(doc, p.sel.XPath(selectors.UnifiedExamTable))
+	if table == nil {
+		return nil, errno.HTMLParseError.WithMessage("failed to find the exam table")
+	}
+
+	// 查找所有考试成绩行
+	if len(htmlquery.Find(table, p.sel.XPath(selectors.UnifiedExamRows))) == 0 {
+		return nil, nil // 这里不返回错误，因为有可能没有考试成绩
+	}
+
+	examTable, err := newHTMLTable(selectors.UnifiedExam, htmlquery.Find(table, `.//tr`), p.sel.Columns(selectors.UnifiedExam))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := examTable.dataRows()
+	if err != nil {
+		return nil, err
+	}
+
+	// 遍历每一行，提取成绩信息
+	for _, row := range rows {
+		exams = append(exams, &model.UnifiedExam{
+			Name:  htmlquery.InnerText(row.cell("name")),
+			Score: htmlquery.InnerText(row.cell("score")),
+			Term:  htmlquery.InnerText(row.cell("term")),
+		})
+	}
+
+	return exams, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// NoticeList 解析通知公告列表的一页，prefix 为教务处官网地址前缀，用于补全通知链接
+func (p *Parser) NoticeList(doc *html.Node, prefix string) ([]*model.NoticeInfo, error) {
+	var list []*model.NoticeInfo
+
+	listNode := htmlquery.FindOne(doc, p.sel.XPath(selectors.NoticeList))
+	if listNode == nil {
+		return nil, errno.HTMLParseError.WithMessage("cannot find the notice list")
+	}
+
+	rows := htmlquery.Find(listNode, p.sel.XPath(selectors.NoticeItems))
+
+	for _, row := range rows {
+		// 提取日期
+		dateNode := htmlquery.FindOne(row, p.sel.XPath(selectors.NoticeItemDate))
+		if dateNode == nil {
+			return nil, errno.HTMLParseError.WithMessage("cannot find the date")
+		}
+		date := strings.TrimSpace(htmlquery.InnerText(dateNode))
+
+		// 提取标题
+		titleNode := htmlquery.FindOne(row, p.sel.XPath(selectors.NoticeItemLink))
+
+		title := strings.TrimSpace(htmlquery.SelectAttr(titleNode, "title"))
+
+		// 提取 URL
+		rawURL := strings.TrimSpace(htmlquery.SelectAttr(titleNode, "href"))
+		rawURL = prefix + rawURL
+
+		convertedURL, wbTreeId, wbNewsId := convertNoticeURL(rawURL, prefix)
+
+		list = append(list, &model.NoticeInfo{
+			Title:    title,
+			URL:      convertedURL,
+			Date:     date,
+			WbTreeId: wbTreeId,
+			WbNewsId: wbNewsId,
+		})
+	}
+
+	return list, nil
+}
+
+// NoticeTotalPages 解析通知公告列表的总页数
+func (p *Parser) NoticeTotalPages(doc *html.Node) (int, error) {
+	totalPagesNode := htmlquery.FindOne(doc, p.sel.XPath(selectors.NoticeTotalPages))
+	if totalPagesNode == nil {
+		return 0, errno.HTMLParseError.WithMessage("未找到总页数")
+	}
+
+	totalPagesStr := htmlquery.InnerText(totalPagesNode)
+	var totalPages int
+	_, err := fmt.Sscanf(totalPagesStr, "%d", &totalPages)
+	if err != nil {
+		return 0, errno.HTMLParseError.WithMessage("解析总页数失败").WithErr(err)
+	}
+	return totalPages, nil
+}
+
+// NoticeDetail 解析通知详情页面的标题、发布时间和正文，URL、WbTreeId 和 WbNewsId 由调用方填写
+func (p *Parser) NoticeDetail(doc *html.Node) (*model.NoticeDetail, error) {
+	// 主容器
+	mainNode := htmlquery.FindOne(doc, p.sel.XPath(selectors.NoticeDetail))
+	if mainNode == nil {
+		return nil, errno.HTMLParseError.WithMessage(".xl_main not found")
+	}
+
+	// 提取标题
+	titleNode := htmlquery.FindOne(mainNode, p.sel.XPath(selectors.NoticeTitle))
+	if titleNode == nil {
+		return nil, errno.HTMLParseError.WithMessage(".xl_tit h4 not found")
+	}
+	title := strings.TrimSpace(htmlquery.InnerText(titleNode))
+
+	// 提取发布时间
+	timeNode := htmlquery.FindOne(mainNode, p.sel.XPath(selectors.NoticeTime))
+	if timeNode == nil {
+		return nil, errno.HTMLParseError.WithMessage(".xl_sj span not found")
+	}
+	date := strings.TrimPrefix(strings.TrimSpace(htmlquery.InnerText(timeNode)), "发布时间：")
+
+	// 提取内容
+	contentNode := htmlquery.FindOne(mainNode, p.sel.XPath(selectors.NoticeContent))
+	if contentNode == nil {
+		return nil, errno.HTMLParseError.WithMessage("#vsb_content not found")
+	}
+
+	return &model.NoticeDetail{
+		NoticeInfo: model.NoticeInfo{
+			Title: title,
+			Date:  date,
+		},
+		Content: htmlquery.InnerText(contentNode),
+	}, nil
+}
+
+// noticeURLRegexp 匹配 info/TREE/NEWS.htm 格式的通知链接
+var noticeURLRegexp = regexp.MustCompile(`info/(\d+)/(\d+)\.htm`)
+
+// 将通知公告列表中的 URL 转换成 content.jsp 格式，并提取 wbtreeid 和 wbnewsid
+//
+// 例：将
+//   - https://jwch.fzu.edu.cn/../info/1040/13769.htm
+//   - https://jwch.fzu.edu.cn/info/1040/13769.htm
+//   - https://jwch.fzu.edu.cn/../content.jsp?urltype=news.NewsContentUrl&wbtreeid=1040&wbnewsid=13769
+//
+// 转换成
+// - https://jwch.fzu.edu.cn/content.jsp?urltype=news.NewsContentUrl&wbtreeid=1040&wbnewsid=13769
+//
+// Returns:
+//   - finalURL
+//   - wbTreeId
+//   - wbNewsId
+func convertNoticeURL(original, prefix string) (string, string, string) {
+	// 去除 "../"
+	cleaned := strings.ReplaceAll(original, "../", "")
+
+	// 正则提取 wbtreeid 和 wbnewsid（info/TREE/NEWS.htm 格式）
+	matches := noticeURLRegexp.FindStringSubmatch(cleaned)
+	if len(matches) == 3 {
+		wbtreeid := matches[1]
+		wbnewsid := matches[2]
+		return noticeDetailURL(prefix, wbtreeid, wbnewsid), wbtreeid, wbnewsid
+	}
+
+	// 已经是 content.jsp 格式，从 query string 中提取 wbtreeid 和 wbnewsid
+	parsed, err := url.Parse(cleaned)
+	if err == nil {
+		q := parsed.Query()
+		wbtreeid := q.Get("wbtreeid")
+		wbnewsid := q.Get("wbnewsid")
+		if wbtreeid != "" && wbnewsid != "" {
+			return cleaned, wbtreeid, wbnewsid
+		}
+	}
+
+	return cleaned, "", ""
+}
+
+// noticeDetailURL 返回通知详情页面的地址，prefix 为教务处官网地址前缀
+func noticeDetailURL(prefix, wbTreeId, wbNewsId string) string {
+	return fmt.Sprintf("%scontent.jsp?urltype=news.NewsContentUrl&wbtreeid=%s&wbnewsid=%s", prefix, wbTreeId, wbNewsId)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/west2-online/jwch/constants"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/parse"
+)
+
+func TestParseNoticeList(t *testing.T) {
+	items := `<li><span class="doclist_time">2025-06-19</span><a href="info/1036/13790.htm" title="通知1">通知1</a></li>` +
+		`<li><span class="doclist_time">2025-06-18</span><a href="../info/1037/13789.htm" title="通知2">通知2</a></li>` +
+		`<li><span class="doclist_time">2025-06-17</span><a href="../content.jsp?urltype=news.NewsContentUrl&amp;wbtreeid=1035&amp;wbnewsid=13788" title="通知3">通知3</a></li>`
+	data := readFixture(t, "notice_list")
+	data = bytes.Replace(data, []byte("{{NOTICES}}"), []byte(items), 1)
+	data = bytes.Replace(data, []byte("{{PAGE}}"), []byte("1"), 1)
+	data = bytes.Replace(data, []byte("{{TOTAL_PAGES}}"), []byte("42"), 1)
+
+	list, err := parse.ParseNoticeList(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := constants.JwchNoticeURLPrefix + "content.jsp?urltype=news.NewsContentUrl&"
+	expected := []model.NoticeInfo{
+		{Title: "通知1", URL: prefix + "wbtreeid=1036&wbnewsid=13790", Date: "2025-06-19", WbTreeId: "1036", WbNewsId: "13790"},
+		{Title: "通知2", URL: prefix + "wbtreeid=1037&wbnewsid=13789", Date: "2025-06-18", WbTreeId: "1037", WbNewsId: "13789"},
+		{Title: "通知3", URL: prefix + "wbtreeid=1035&wbnewsid=13788", Date: "2025-06-17", WbTreeId: "1035", WbNewsId: "13788"},
+	}
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d notices, got %d", len(expected), len(list))
+	}
+	for i, notice := range list {
+		if *notice != expected[i] {
+			t.Errorf("notice %d: expected %+v, got %+v", i, expected[i], *notice)
+		}
+	}
+
+	doc, err := parse.Document(bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pages, err := parse.New(nil).NoticeTotalPages(doc); err != nil || pages != 42 {
+		t.Errorf("unexpected total pages: %d, %v", pages, err)
+	}
+}
+
+func TestParseNoticeDetail(t *testing.T) {
+	detail, err := parse.ParseNoticeDetail(bytes.NewReader(readFixture(t, "notice_detail")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.Title != "关于2025年春季学期期末考试安排的通知" || detail.Date != "2025-06-01" {
+		t.Errorf("unexpected notice: %+v", detail.NoticeInfo)
+	}
+	if detail.Content != "各学院：2025年春季学期期末考试定于第18-19周进行，请各学院做好相关准备工作。" {
+		t.Errorf("unexpected content: %q", detail.Content)
+	}
+
+	if _, err := parse.ParseNoticeDetail(bytes.NewReader([]byte("<html></html>"))); !errors.Is(err, errno.HTMLParseError) {
+		t.Errorf("expected HTMLParseError, got %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package parse 解析教务处页面，不依赖网络请求
+// Student 的各个方法在请求后调用这里的函数，也可以用来重新解析保存下来的页面
+package parse
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/west2-online/jwch/constants"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// Parser 使用一组固定的选择器解析页面，可以并发使用
+type Parser struct {
+	sel *selectors.Set
+}
+
+// New 创建使用 set 的 Parser，set 为 nil 时使用 selectors.DefaultRegistry 当前的选择器
+func New(set *selectors.Set) *Parser {
+	if set == nil {
+		set = selectors.DefaultRegistry.Current()
+	}
+	return &Parser{sel: set}
+}
+
+// Document 读取并解析 HTML 页面，编码的识别见 Decode
+func Document(r io.Reader, contentType string) (*html.Node, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errno.HTMLParseError.WithErr(err)
+	}
+	data, err = Decode(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := htmlquery.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, errno.HTMLParseError.WithErr(err)
+	}
+	return doc, nil
+}
+
+// Decode 按 BOM、contentType（HTTP 响应的 Content-Type，可以为空）和 meta 标签识别编码后转换为 UTF-8
+// 没有声明编码且内容不是合法的 UTF-8 时按 GB18030 解码，教务处 :82 端口的页面使用 GB2312
+func Decode(data []byte, contentType string) ([]byte, error) {
+	enc, name, certain := charset.DetermineEncoding(data, contentType)
+	if !certain && name == "windows-1252" {
+		// 没有找到任何编码声明，DetermineEncoding 只检查了前 1024 个字节
+		enc = encoding.Nop
+		if !utf8.Valid(data) {
+			enc = simplifiedchinese.GB18030
+		}
+	}
+	if enc == encoding.Nop {
+		return data, nil
+	}
+
+	res, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, errno.HTMLParseError.WithMessage("failed to decode " + name + " page").WithErr(err)
+	}
+	return res, nil
+}
+
+// ParseMarks 解析成绩查询页面，见 Student.GetMarks
+func ParseMarks(r io.Reader) ([]*model.Mark, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).Marks(doc)
+}
+
+// ParseTerms 解析选课页面的学期列表，见 Student.GetTerms
+func ParseTerms(r io.Reader) (*model.Term, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).Terms(doc)
+}
+
+// ParseCourses 解析选课结果页面，见 Student.GetSemesterCourses，课程大纲等链接使用 constants.JwchPrefix 补全
+func ParseCourses(r io.Reader) ([]*model.Course, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).Courses(doc, constants.JwchPrefix)
+}
+
+// ParseCredit 解析学分统计页面，见 Student.GetCredit
+func ParseCredit(r io.Reader) ([]*model.CreditStatistics, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).Credit(doc)
+}
+
+// ParseCreditV2 解析学分统计页面，分别返回主修和辅修专业的学分，见 Student.GetCreditV2
+func ParseCreditV2(r io.Reader) (majorCredits, minorCredits []*model.CreditStatistics, err error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return New(nil).CreditV2(doc)
+}
+
+// ParseGPA 解析绩点排名页面，见 Student.GetGPA
+func ParseGPA(r io.Reader) (*model.GPABean, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).GPA(doc)
+}
+
+// ParseInfo 解析个人信息页面，见 Student.GetInfo
+func ParseInfo(r io.Reader) (*model.StudentDetail, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).Info(doc)
+}
+
+// ParseLectures 解析讲座页面，见 Student.GetLectures
+func ParseLectures(r io.Reader) ([]*model.Lecture, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).Lectures(doc)
+}
+
+// ParseExamRooms 解析考场查询页面，见 Student.GetExamRoom
+func ParseExamRooms(r io.Reader) ([]*model.ExamRoomInfo, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).ExamRooms(doc)
+}
+
+// ParseEmptyRooms 解析空教室查询结果页面，见 Student.GetEmptyRoom
+func ParseEmptyRooms(r io.Reader) ([]string, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).EmptyRooms(doc), nil
+}
+
+// ParseUnifiedExams 解析 CET 或省计算机成绩页面，见 Student.GetCET 和 Student.GetJS
+func ParseUnifiedExams(r io.Reader) ([]*model.UnifiedExam, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).UnifiedExams(doc)
+}
+
+// ParseTermEvents 解析校历中 termId 学期的安排，见 Student.GetTermEvents
+func ParseTermEvents(r io.Reader, termId string) (*model.CalTermEvents, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).TermEvents(doc, termId)
+}
+
+// ParseSchoolCalendar 解析校历页面，见 Student.GetSchoolCalendar
+func ParseSchoolCalendar(r io.Reader) (*model.SchoolCalendar, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).SchoolCalendar(doc)
+}
+
+// ParseLocateDate 解析当前周次页面，见 Student.GetLocateDate
+func ParseLocateDate(r io.Reader) (*model.LocateDate, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errno.HTMLParseError.WithErr(err)
+	}
+	data, err = Decode(data, "")
+	if err != nil {
+		return nil, err
+	}
+	return LocateDate(string(data))
+}
+
+// ParseNoticeList 解析通知公告列表的一页，见 Student.GetNoticeInfo，通知链接使用 constants.JwchNoticeURLPrefix 补全
+func ParseNoticeList(r io.Reader) ([]*model.NoticeInfo, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).NoticeList(doc, constants.JwchNoticeURLPrefix)
+}
+
+// ParseNoticeDetail 解析通知详情页面，见 Student.GetNoticeDetail，只填写标题、发布时间和正文
+func ParseNoticeDetail(r io.Reader) (*model.NoticeDetail, error) {
+	doc, err := Document(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return New(nil).NoticeDetail(doc)
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/west2-online/jwch/parse"
+)
+
+// readFixture 读取 jwchtest 模拟服务器使用的页面
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../jwchtest/testdata/" + name + ".html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecode(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String("教务处")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]struct {
+		data        string
+		contentType string
+	}{
+		"utf-8":        {data: "教务处"},
+		"bom":          {data: "\xef\xbb\xbf教务处"},
+		"content type": {data: gbk, contentType: "text/html; charset=gbk"},
+		"meta":         {data: `<meta charset="gb2312">` + gbk},
+		"fallback":     {data: gbk},
+	}
+	for name, c := range cases {
+		res, err := parse.Decode([]byte(c.data), c.contentType)
+		if err != nil || !strings.HasSuffix(string(res), "教务处") {
+			t.Errorf("%s: got %q, %v", name, res, err)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// PlanColleges 解析培养方案页面的学院下拉框
+func (p *Parser) PlanColleges(doc *html.Node) ([]model.Option, error) {
+	return p.options(doc, selectors.PlanColleges, "college select not found")
+}
+
+// PlanMajors 解析选择年级和学院后培养方案页面的专业下拉框
+func (p *Parser) PlanMajors(doc *html.Node) ([]model.Option, error) {
+	return p.options(doc, selectors.PlanMajors, "major select not found")
+}
+
+func (p *Parser) options(doc *html.Node, name selectors.Name, notFound string) ([]model.Option, error) {
+	list := htmlquery.FindOne(doc, p.sel.XPath(name))
+	if list == nil {
+		return nil, errno.HTMLParseError.WithMessage(notFound)
+	}
+
+	var res []model.Option
+	for _, option := range htmlquery.Find(list, p.sel.XPath(selectors.PlanOptions)) {
+		res = append(res, model.Option{
+			Text:  htmlquery.InnerText(option),
+			Value: htmlquery.SelectAttr(option, "value"),
+		})
+	}
+	return res, nil
+}
+
+// PlanURL 在只选择年级的培养方案查询结果中查找 major 专业的培养方案，prefix 为教务系统地址前缀
+func (p *Parser) PlanURL(doc *html.Node, major, prefix string) (string, error) {
+	xpathExpr := fmt.Sprintf("//tr[td[matches(string(.), '^（.*?）%s$')]]/td/a[contains(@href, 'pyfa')]/@href", regexp.QuoteMeta(major))
+	node := htmlquery.FindOne(doc, xpathExpr)
+	if node == nil {
+		return "", errno.HTMLParseError.WithMessage("cultivate plan not found for major: " + major)
+	}
+
+	url := htmlquery.SelectAttr(node, "href")
+	return prefix + "/pyfa/pyjh/" + strings.TrimPrefix(strings.TrimSuffix(url, "')"), "javascript:pop1('"), nil
+}
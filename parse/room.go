@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// ViewState 返回 ASP.NET 页面回发时需要带回的隐藏字段，键为 VIEWSTATE 和 EVENTVALIDATION，找不到时为空字符串
+func (p *Parser) ViewState(doc *html.Node) map[string]string {
+	return map[string]string{
+		"VIEWSTATE":       htmlquery.SelectAttr(htmlquery.FindOne(doc, p.sel.XPath(selectors.ViewState)), "value"),
+		"EVENTVALIDATION": htmlquery.SelectAttr(htmlquery.FindOne(doc, p.sel.XPath(selectors.EventValidation)), "value"),
+	}
+}
+
+// RoomTypes 解析空教室页面选择校区（教学楼）后的教室类型
+func (p *Parser) RoomTypes(doc *html.Node) []string {
+	var types []string
+	for _, opt := range htmlquery.Find(doc, p.sel.XPath(selectors.RoomTypes)) {
+		types = append(types, htmlquery.InnerText(opt))
+	}
+	return types
+}
+
+// EmptyRooms 解析空教室页面查询结果中的教室
+func (p *Parser) EmptyRooms(doc *html.Node) []string {
+	if doc == nil {
+		return nil
+	}
+	var res []string
+	for _, opt := range htmlquery.Find(doc, p.sel.XPath(selectors.RoomList)) {
+		res = append(res, htmlquery.InnerText(opt))
+	}
+	return res
+}
+
+// ExamRooms 解析考场查询页面，页面中没有考场表格时返回 nil, nil
+func (p *Parser) ExamRooms(doc *html.Node) ([]*model.ExamRoomInfo, error) {
+	var examInfos []*model.ExamRoomInfo
+	table := htmlquery.FindOne(doc, p.sel.XPath(selectors.ExamRoomTable))
+	if table == nil {
+		return nil, nil
+	}
+	examRooms, err := newHTMLTable(selectors.ExamRooms, htmlquery.Find(table, ".//tr"), p.sel.Columns(selectors.ExamRooms))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := examRooms.dataRows()
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		// 获取每一列的内容
+		courseName := row.text("course_name")
+		credit := row.text("credit")
+		teacher := row.text("teacher")
+		dateTimeAndLocation := row.text("exam_time")
+		// example: 2024年11月17日 12:30-17:30  旗山数计3-404
+		date, time, location := parseDateAndLocation(dateTimeAndLocation)
+		// 将数据存入结构体
+		examInfo := &model.ExamRoomInfo{
+			CourseName: courseName,
+			Credit:     credit,
+			Teacher:    teacher,
+			Date:       date,
+			Time:       time,
+			Location:   location,
+			Exam:       examSchedule(dateTimeAndLocation),
+		}
+		examInfos = append(examInfos, examInfo)
+	}
+	return examInfos, nil
+}
+
+// 将日期和地点分开，保留页面中的原文，解析后的时间见 ExamRoomInfo.Exam
+func parseDateAndLocation(dateAndLocation string) (date, time, location string) {
+	if dateAndLocation == "" {
+		return "", "", "暂无考场数据"
+	}
+	array := strings.Fields(dateAndLocation)
+	if len(array) > 0 {
+		date = array[0]
+	}
+	if len(array) > 1 {
+		time = array[1]
+	}
+	if len(array) > 2 {
+		location = strings.Join(array[2:], " ")
+	}
+	return
+}
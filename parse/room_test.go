@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/parse"
+)
+
+func TestParseTerms(t *testing.T) {
+	page := strings.NewReplacer("{{VIEWSTATE}}", "vs", "{{EVENTVALIDATION}}", "ev").Replace(string(readFixture(t, "course_terms")))
+	term, err := parse.ParseTerms(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if term.ViewState != "vs" || term.EventValidation != "ev" {
+		t.Errorf("unexpected state: %q %q", term.ViewState, term.EventValidation)
+	}
+	if expected := []string{"202501", "202402", "202401", "202302"}; !reflect.DeepEqual(term.Terms, expected) {
+		t.Errorf("unexpected terms: %v", term.Terms)
+	}
+
+	if _, err := parse.ParseTerms(strings.NewReader("<html></html>")); !errors.Is(err, errno.HTMLParseError) {
+		t.Errorf("expected HTMLParseError, got %v", err)
+	}
+}
+
+func TestParseEmptyRoom(t *testing.T) {
+	page := strings.NewReplacer(
+		"{{ID}}", "1",
+		"{{VIEWSTATE}}", "vs",
+		"{{EVENTVALIDATION}}", "ev",
+		"{{BUILDINGS}}", `<option value="西三">西三</option>`,
+		"{{ROOM_TYPES}}", `<option value="多媒体">多媒体</option><option value="普通">普通</option>`,
+		"{{ROOMS}}", `<option value="西三-101">西三-101</option><option value="西三-102">西三-102</option>`,
+	).Replace(string(readFixture(t, "empty_room")))
+
+	doc, err := parse.Document(strings.NewReader(page), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := parse.New(nil)
+	if state := p.ViewState(doc); state["VIEWSTATE"] != "vs" || state["EVENTVALIDATION"] != "ev" {
+		t.Errorf("unexpected state: %v", state)
+	}
+	if types := p.RoomTypes(doc); !reflect.DeepEqual(types, []string{"多媒体", "普通"}) {
+		t.Errorf("unexpected room types: %v", types)
+	}
+
+	rooms, err := parse.ParseEmptyRooms(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rooms, []string{"西三-101", "西三-102"}) {
+		t.Errorf("unexpected rooms: %v", rooms)
+	}
+	if p.EmptyRooms(nil) != nil {
+		t.Error("expected no rooms for a nil document")
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// htmlTable 按表头文字定位列的教务处表格
+type htmlTable struct {
+	name    selectors.Table // 表格名称，用于错误信息
+	columns map[string]int  // 字段到列序号
+	width   int             // 表头的列数
+	rows    []*html.Node    // 表头之后的行
+}
+
+// tableRow 表格中的一个数据行
+type tableRow struct {
+	table *htmlTable
+	cells []*html.Node
+}
+
+// newHTMLTable 在 rows 中查找包含 columns 中全部表头的表头行，表头之前的标题行和空行会被忽略
+// columns 为字段到表头文字的映射，见 selectors.Set.Columns
+// 表头文字比较时忽略空白字符，找不到表头时返回说明缺少哪些列的 HTMLParseError
+func newHTMLTable(name selectors.Table, rows []*html.Node, columns map[string]string) (*htmlTable, error) {
+	fields := slices.Sorted(maps.Keys(columns))
+	var missing []string
+	for i, row := range rows {
+		cells := htmlquery.Find(row, "./td")
+		headers := make(map[string]int, len(cells))
+		for j, cell := range cells {
+			text := selectors.HeaderText(htmlquery.InnerText(cell))
+			if _, ok := headers[text]; !ok {
+				headers[text] = j
+			}
+		}
+
+		var lacks []string
+		index := make(map[string]int, len(columns))
+		for _, field := range fields {
+			j, ok := headers[selectors.HeaderText(columns[field])]
+			if !ok {
+				lacks = append(lacks, columns[field])
+				continue
+			}
+			index[field] = j
+		}
+		if len(lacks) == 0 {
+			return &htmlTable{name: name, columns: index, width: len(cells), rows: rows[i+1:]}, nil
+		}
+		// 记录最接近表头的一行缺少的列
+		if missing == nil || len(lacks) < len(missing) {
+			missing = lacks
+		}
+	}
+	if missing == nil {
+		for _, field := range fields {
+			missing = append(missing, columns[field])
+		}
+	}
+	return nil, errno.HTMLParseError.WithMessage(fmt.Sprintf("%s table: header %s not found", name, strings.Join(missing, ", ")))
+}
+
+// dataRows 返回表头之后的数据行，跳过空行和只有一个合并单元格的标题行
+// 列数与表头不一致的行返回 HTMLParseError，不会错位解析
+func (t *htmlTable) dataRows() ([]tableRow, error) {
+	res := make([]tableRow, 0, len(t.rows))
+	for i, row := range t.rows {
+		cells := htmlquery.Find(row, "./td")
+		if isFillerRow(cells) {
+			continue
+		}
+		if len(cells) != t.width {
+			return nil, errno.HTMLParseError.WithMessage(
+				fmt.Sprintf("%s table: row %d has %d cells, header has %d", t.name, i+1, len(cells), t.width))
+		}
+		res = append(res, tableRow{table: t, cells: cells})
+	}
+	return res, nil
+}
+
+// cell 返回字段 field 所在的单元格，字段不存在时返回 nil
+func (r tableRow) cell(field string) *html.Node {
+	i, ok := r.table.columns[field]
+	if !ok {
+		return nil
+	}
+	return r.cells[i]
+}
+
+// text 返回字段 field 所在单元格去除首尾空白后的文本
+func (r tableRow) text(field string) string {
+	cell := r.cell(field)
+	if cell == nil {
+		return ""
+	}
+	return strings.TrimSpace(htmlquery.InnerText(cell))
+}
+
+func isFillerRow(cells []*html.Node) bool {
+	if len(cells) == 1 && htmlquery.SelectAttr(cells[0], "colspan") != "" {
+		return true
+	}
+	for _, cell := range cells {
+		if selectors.HeaderText(htmlquery.InnerText(cell)) != "" {
+			return false
+		}
+	}
+	return true
+}
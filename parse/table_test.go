@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/parse"
+)
+
+// dataList 生成教务处 DataList 表格页面，rows 为每行的单元格
+func dataList(rows ...[]string) []byte {
+	var b strings.Builder
+	b.WriteString(`<html><body><form id="form1">`)
+	b.WriteString(`<input type="hidden" id="__VIEWSTATE" value="{{VIEWSTATE}}" /><input type="hidden" id="__EVENTVALIDATION" value="{{EVENTVALIDATION}}" />`)
+	b.WriteString(`<table id="ContentPlaceHolder1_DataList_xxk"><tbody>`)
+	b.WriteString(`<tr><td colspan="12" align="center">标题</td></tr>`)
+	for i, row := range rows {
+		if i == 0 {
+			b.WriteString(`<tr style="height:30px; background:#efefef;">`)
+		} else {
+			b.WriteString(`<tr style="height:30px;" onmouseover="c=this.style.backgroundColor">`)
+		}
+		for _, cell := range row {
+			b.WriteString("<td>" + cell + "</td>")
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString(`<tr><td colspan="12"></td></tr></tbody></table></form></body></html>`)
+	return []byte(b.String())
+}
+
+func TestParseLayoutMismatch(t *testing.T) {
+	page := dataList(
+		[]string{"修读类别", "开课学期", "课程名称", "计划学分", "成绩", "绩点", "获得学分", "选课类型", "考试类别", "任课教师", "上课时间地点", "考试时间地点"},
+	)
+	_, err := parse.ParseMarks(strings.NewReader(string(page)))
+	var e errno.ErrNo
+	if !errors.Is(err, errno.HTMLParseError) || !errors.As(err, &e) || e.ErrorMsg != "marks table: header 得分 not found" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// 缺少表头时不能把数据行当作表头
+func TestParseHeaderlessTable(t *testing.T) {
+	page := dataList(
+		[]string{"主修", "202401", "高数", "<span>5.0</span>", `<font color="blue">92</font>`, "4.0", "5.0", "必修", "考试", "林老师", "", ""},
+		[]string{"主修", "202401", "物理", "<span>4.0</span>", `<font color="blue">85</font>`, "3.7", "4.0", "必修", "考试", "郑老师", "", ""},
+	)
+	marks, err := parse.ParseMarks(strings.NewReader(string(page)))
+	if !errors.Is(err, errno.HTMLParseError) {
+		t.Errorf("expected HTMLParseError, got %+v, %v", marks, err)
+	}
+}
+
+// 列数与表头不一致的成绩行返回错误，没有 style 的行不是成绩，直接跳过
+func TestParseIrregularRows(t *testing.T) {
+	header := []string{"修读类别", "开课学期", "课程名称", "计划学分", "得分", "绩点", "获得学分", "选课类型", "考试类别", "任课教师", "上课时间地点", "考试时间地点"}
+	grade := []string{"主修", "202401", "高数", "<span>5.0</span>", `<font color="blue">92</font>`, "4.0", "5.0", "必修", "考试", "林老师", "", ""}
+
+	page := strings.Replace(string(dataList(header, grade)), `<tr><td colspan="12"></td></tr>`, `<tr><td>备注</td><td>x</td></tr><tr><td colspan="12"></td></tr>`, 1)
+	marks, err := parse.ParseMarks(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(marks) != 1 || marks[0].Name != "高数" {
+		t.Errorf("unexpected marks: %+v", marks)
+	}
+
+	_, err = parse.ParseMarks(strings.NewReader(string(dataList(header, grade, grade[:11]))))
+	var e errno.ErrNo
+	if !errors.Is(err, errno.HTMLParseError) || !errors.As(err, &e) || e.ErrorMsg != "marks table: row 2 has 11 cells, header has 12" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package parse
+
+import (
+	"golang.org/x/net/html"
+
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// Info 解析个人信息页面，找不到的字段留空
+func (p *Parser) Info(doc *html.Node) (*model.StudentDetail, error) {
+	return &model.StudentDetail{
+		Name:             safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserName)),
+		Birthday:         safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserBirthday)),
+		Sex:              safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserSex)),
+		Phone:            safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserPhone)),
+		Email:            safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserEmail)),
+		College:          safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserCollege)),
+		Grade:            safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserGrade)),
+		StatusChanges:    safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserStatusChanges)),
+		Major:            safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserMajor)),
+		Counselor:        safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserCounselor)),
+		ExamineeCategory: safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserExamineeCategory)),
+		Nationality:      safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserNationality)),
+		Country:          safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserCountry)),
+		PoliticalStatus:  safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserPoliticalStatus)),
+		Source:           safeExtractHTMLFirst(doc, p.sel.XPath(selectors.UserSource)),
+	}, nil
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"slices"
+	"time"
+
+	"github.com/west2-online/jwch/model"
+	"github.com/west2-online/jwch/parse"
+)
+
+// 校区名称
+const (
+	CampusQiShan   = model.CampusQiShan
+	CampusTongPan  = model.CampusTongPan
+	CampusYiShan   = model.CampusYiShan
+	CampusXiaMen   = model.CampusXiaMen
+	CampusQuanGang = model.CampusQuanGang
+	CampusJinJiang = model.CampusJinJiang
+)
+
+// shanghai 教务处使用的时区，作息和导出的日历都按该时区计算
+var shanghai = parse.Shanghai
+
+// DefaultCampus 无法从上课地点推断校区时使用的校区
+const DefaultCampus = CampusQiShan
+
+// ClassPeriod 一节课的上下课时间，为距离当天零点的时长
+type ClassPeriod struct {
+	Start time.Duration `json:"start"` // 上课时间
+	End   time.Duration `json:"end"`   // 下课时间
+}
+
+// ClassInterval 一次课的具体上下课时间
+type ClassInterval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ClassPeriods 一个校区的作息时间表，下标为节数减一
+type ClassPeriods struct {
+	Campus     string        `json:"campus"`      // 校区
+	Summer     []ClassPeriod `json:"summer"`      // 夏季作息
+	Winter     []ClassPeriod `json:"winter"`      // 冬季作息，为空时全年使用夏季作息
+	SummerFrom time.Month    `json:"summer_from"` // 夏季作息从该月 1 日开始
+	WinterFrom time.Month    `json:"winter_from"` // 冬季作息从该月 1 日开始
+}
+
+func clock(hour, minute int) time.Duration {
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+}
+
+func period(startHour, startMinute, endHour, endMinute int) ClassPeriod {
+	return ClassPeriod{Start: clock(startHour, startMinute), End: clock(endHour, endMinute)}
+}
+
+// 晚上的课各校区相同
+var eveningPeriods = []ClassPeriod{
+	period(19, 0, 19, 45),
+	period(19, 55, 20, 40),
+	period(20, 50, 21, 35),
+}
+
+// 铜盘、怡山校区上午的作息
+var cityMorningPeriods = []ClassPeriod{
+	period(8, 0, 8, 45),
+	period(8, 55, 9, 40),
+	period(10, 0, 10, 45),
+	period(10, 55, 11, 40),
+}
+
+// 内置的作息时间表，与学校实际不符时可以自行构造 ClassPeriods
+var builtinClassPeriods = map[string]ClassPeriods{
+	// 旗山校区不区分冬夏季作息，全年使用同一张作息表，因此没有 Winter
+	// 学校调整作息时可以通过 ICSOptions.ClassPeriods 覆盖
+	CampusQiShan: {
+		Campus: CampusQiShan,
+		Summer: slices.Concat([]ClassPeriod{
+			period(8, 20, 9, 5),
+			period(9, 15, 10, 0),
+			period(10, 20, 11, 5),
+			period(11, 15, 12, 0),
+			period(14, 0, 14, 45),
+			period(14, 55, 15, 40),
+			period(15, 50, 16, 35),
+			period(16, 45, 17, 30),
+		}, eveningPeriods),
+	},
+	CampusTongPan: cityClassPeriods(CampusTongPan),
+	CampusYiShan:  cityClassPeriods(CampusYiShan),
+	CampusXiaMen: {
+		Campus: CampusXiaMen,
+		Summer: slices.Concat(cityMorningPeriods, []ClassPeriod{
+			period(15, 0, 15, 45),
+			period(15, 55, 16, 40),
+			period(16, 50, 17, 35),
+			period(17, 45, 18, 30),
+		}, eveningPeriods),
+		Winter: slices.Concat(cityMorningPeriods, []ClassPeriod{
+			period(14, 30, 15, 15),
+			period(15, 25, 16, 10),
+			period(16, 20, 17, 5),
+			period(17, 15, 18, 0),
+		}, eveningPeriods),
+		SummerFrom: time.May,
+		WinterFrom: time.October,
+	},
+}
+
+// cityClassPeriods 铜盘、怡山校区的作息，夏季下午推迟半小时
+func cityClassPeriods(campus string) ClassPeriods {
+	return ClassPeriods{
+		Campus: campus,
+		Summer: slices.Concat(cityMorningPeriods, []ClassPeriod{
+			period(14, 30, 15, 15),
+			period(15, 25, 16, 10),
+			period(16, 20, 17, 5),
+			period(17, 15, 18, 0),
+		}, eveningPeriods),
+		Winter: slices.Concat(cityMorningPeriods, []ClassPeriod{
+			period(14, 0, 14, 45),
+			period(14, 55, 15, 40),
+			period(15, 50, 16, 35),
+			period(16, 45, 17, 30),
+		}, eveningPeriods),
+		SummerFrom: time.May,
+		WinterFrom: time.October,
+	}
+}
+
+// DefaultClassPeriods 返回内置的某个校区的作息时间表
+func DefaultClassPeriods(campus string) (ClassPeriods, bool) {
+	p, ok := builtinClassPeriods[campus]
+	if !ok {
+		return ClassPeriods{}, false
+	}
+	p.Summer = slices.Clone(p.Summer)
+	p.Winter = slices.Clone(p.Winter)
+	return p, true
+}
+
+// InferCampus 根据上课地点推断校区，例如 旗山西1-206 为旗山校区，无法推断时返回空字符串
+func InferCampus(location string) string {
+	campus, _, _ := parse.SplitLocation(location)
+	return campus
+}
+
+// Periods 返回 date 当天使用的作息
+func (p ClassPeriods) Periods(date time.Time) []ClassPeriod {
+	if len(p.Winter) == 0 || p.SummerFrom == 0 || p.WinterFrom == 0 {
+		return p.Summer
+	}
+
+	month := date.In(shanghai).Month()
+	summer := month >= p.SummerFrom && month < p.WinterFrom
+	if p.SummerFrom > p.WinterFrom {
+		// 夏季作息跨年的情况
+		summer = month >= p.SummerFrom || month < p.WinterFrom
+	}
+	if summer {
+		return p.Summer
+	}
+	return p.Winter
+}
+
+// Interval 返回 date 当天第 startClass 节到第 endClass 节的上下课时间
+func (p ClassPeriods) Interval(date time.Time, startClass, endClass int) (ClassInterval, bool) {
+	periods := p.Periods(date)
+	if startClass < 1 || endClass < startClass || endClass > len(periods) {
+		return ClassInterval{}, false
+	}
+
+	date = date.In(shanghai)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, shanghai)
+	return ClassInterval{
+		Start: day.Add(periods[startClass-1].Start),
+		End:   day.Add(periods[endClass-1].End),
+	}, true
+}
+
+// Resolve 返回规则在第 week 周的上下课时间，termStart 为学期第一周的任意一天
+// week 不在规则的周次范围内或单双周不匹配时返回 false
+func (p ClassPeriods) Resolve(rule CourseScheduleRule, week int, termStart time.Time) (ClassInterval, bool) {
+	if !slices.Contains(ScheduleRuleWeeks(rule), week) {
+		return ClassInterval{}, false
+	}
+	return p.Interval(WeekDate(termStart, week, rule.Weekday), rule.StartClass, rule.EndClass)
+}
+
+// ResolveScheduleRule 根据上课地点推断校区，返回规则在第 week 周的上下课时间
+func ResolveScheduleRule(rule CourseScheduleRule, week int, termStart time.Time) (ClassInterval, bool) {
+	return classPeriodsOf(nil, rule.Location).Resolve(rule, week, termStart)
+}
+
+// ScheduleRuleWeeks 返回规则实际上课的周次，已经考虑单双周
+func ScheduleRuleWeeks(rule CourseScheduleRule) []int {
+	var weeks []int
+	for week := rule.StartWeek; week <= rule.EndWeek; week++ {
+		if (week%2 == 1 && rule.Single) || (week%2 == 0 && rule.Double) {
+			weeks = append(weeks, week)
+		}
+	}
+	return weeks
+}
+
+// WeekDate 返回第 week 周星期 weekday（1-7）的日期，termStart 为学期第一周的任意一天
+func WeekDate(termStart time.Time, week, weekday int) time.Time {
+	return weekStart(termStart).AddDate(0, 0, (week-1)*7+weekday-1)
+}
+
+// weekStart 返回 t 所在周的周一零点（Asia/Shanghai）
+func weekStart(t time.Time) time.Time {
+	t = t.In(shanghai)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, shanghai)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// classPeriodsOf 返回上课地点对应校区的作息，custom 中的作息优先于内置作息
+func classPeriodsOf(custom map[string]ClassPeriods, location string) ClassPeriods {
+	campus := InferCampus(location)
+	if campus == "" {
+		campus = DefaultCampus
+	}
+	if p, ok := custom[campus]; ok {
+		return p
+	}
+	if p, ok := builtinClassPeriods[campus]; ok {
+		return p
+	}
+	if p, ok := custom[DefaultCampus]; ok {
+		return p
+	}
+	return builtinClassPeriods[DefaultCampus]
+}
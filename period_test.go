@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInferCampus(t *testing.T) {
+	tests := map[string]string{
+		"旗山东3-101":  CampusQiShan,
+		"铜盘A110":    CampusTongPan,
+		"怡山B203":    CampusYiShan,
+		"集美校区1-101": CampusXiaMen,
+		"鼓浪屿3-202":  CampusXiaMen,
+		"晋江1-101":   CampusJinJiang,
+		"体育场":       "",
+	}
+	for location, campus := range tests {
+		if got := InferCampus(location); got != campus {
+			t.Errorf("InferCampus(%q) = %q, want %q", location, got, campus)
+		}
+	}
+}
+
+func TestResolveScheduleRule(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*60*60)
+	termStart := time.Date(2025, 9, 3, 0, 0, 0, 0, shanghai) // 第一周周三
+	rule := CourseScheduleRule{
+		Location: "铜盘A110", StartClass: 5, EndClass: 6,
+		StartWeek: 1, EndWeek: 16, Weekday: 2, Double: true,
+	}
+
+	if weeks := ScheduleRuleWeeks(rule); len(weeks) != 8 || weeks[0] != 2 || weeks[7] != 16 {
+		t.Errorf("unexpected weeks %v", weeks)
+	}
+	if _, ok := ResolveScheduleRule(rule, 3, termStart); ok {
+		t.Errorf("single week should not resolve for a double-week rule")
+	}
+
+	// 9 月为夏季作息，10 月起为冬季作息
+	tests := []struct {
+		week       int
+		start, end time.Time
+	}{
+		{2, time.Date(2025, 9, 9, 14, 30, 0, 0, shanghai), time.Date(2025, 9, 9, 16, 10, 0, 0, shanghai)},
+		{6, time.Date(2025, 10, 7, 14, 0, 0, 0, shanghai), time.Date(2025, 10, 7, 15, 40, 0, 0, shanghai)},
+	}
+	for _, tt := range tests {
+		got, ok := ResolveScheduleRule(rule, tt.week, termStart)
+		if !ok || !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) {
+			t.Errorf("week %d: got %v-%v (%t), want %v-%v", tt.week, got.Start, got.End, ok, tt.start, tt.end)
+		}
+	}
+
+	// 无法推断校区时使用旗山校区的作息
+	rule.Location = ""
+	got, ok := ResolveScheduleRule(rule, 2, termStart)
+	if !ok || !got.Start.Equal(time.Date(2025, 9, 9, 14, 0, 0, 0, shanghai)) {
+		t.Errorf("unexpected default campus interval %v (%t)", got.Start, ok)
+	}
+
+	rule.EndClass = 12
+	if _, ok = ResolveScheduleRule(rule, 2, termStart); ok {
+		t.Errorf("out of range class should not resolve")
+	}
+}
+
+func TestClassPeriodsOverride(t *testing.T) {
+	periods, ok := DefaultClassPeriods(CampusQiShan)
+	if !ok || len(periods.Summer) != 11 {
+		t.Fatalf("unexpected built-in periods %+v", periods)
+	}
+	if _, ok = DefaultClassPeriods("不存在的校区"); ok {
+		t.Errorf("unknown campus should not have built-in periods")
+	}
+
+	// 修改返回值不影响内置作息
+	periods.Summer[8] = ClassPeriod{Start: 18*time.Hour + 30*time.Minute, End: 19*time.Hour + 15*time.Minute}
+	if again, _ := DefaultClassPeriods(CampusQiShan); again.Summer[8] == periods.Summer[8] {
+		t.Errorf("built-in periods were modified")
+	}
+
+	course := &Course{
+		Name: "形势与政策",
+		ScheduleRules: []CourseScheduleRule{
+			{Location: "旗山东1-201", StartClass: 9, EndClass: 9, StartWeek: 1, EndWeek: 1, Weekday: 1, Single: true, Double: true},
+		},
+	}
+	out, err := ExportICS([]*Course{course}, ICSOptions{
+		TermStart:    time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+		ClassPeriods: map[string]ClassPeriods{CampusQiShan: periods},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "DTSTART;TZID=Asia/Shanghai:20250901T183000\r\n") {
+		t.Errorf("custom periods not applied:\n%s", out)
+	}
+
+	if d := WeekDate(time.Date(2025, 9, 7, 12, 0, 0, 0, time.UTC), 2, 7); d.Format("20060102") != "20250914" {
+		t.Errorf("unexpected week date %v", d)
+	}
+	if !slices.Equal(ScheduleRuleWeeks(CourseScheduleRule{StartWeek: 3, EndWeek: 7, Single: true}), []int{3, 5, 7}) {
+		t.Errorf("unexpected single weeks")
+	}
+}
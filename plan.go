@@ -17,79 +17,85 @@ limitations under the License.
 package jwch
 
 import (
+	"context"
 	"fmt"
-	"regexp"
 	"strings"
+	"time"
 
-	"github.com/antchfx/htmlquery"
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/selectors"
 
-	"github.com/west2-online/jwch/constants"
+	"github.com/antchfx/htmlquery"
 )
 
 func (s *Student) GetCultivatePlan() (string, error) {
-	info, err := s.GetInfo()
+	return s.GetCultivatePlanCtx(context.Background())
+}
+
+// GetCultivatePlanCtx 同 GetCultivatePlan，支持通过 ctx 取消请求
+func (s *Student) GetCultivatePlanCtx(ctx context.Context) (_ string, err error) {
+	ctx = ContextWithOperation(ctx, "GetCultivatePlan")
+	defer s.finish(ctx, time.Now(), &err)
+	info, err := s.GetInfoCtx(ctx)
 	if err != nil {
 		return "", err
 	}
 
 	// 获取初始页面状态
-	viewStateMap, err := s.getState(constants.CultivatePlanURL)
+	viewStateMap, err := s.getState(ctx, s.endpoints.CultivatePlanURL)
 	if err != nil {
 		return "", err
 	}
 
 	// 尝试精确匹配学院和专业代码
-	url, err := s.getCultivatePlanWithPreciseMatch(info, viewStateMap)
+	url, err := s.getCultivatePlanWithPreciseMatch(ctx, info, viewStateMap)
 	if err == nil {
 		return url, nil
 	}
 
 	// 如果精确匹配失败，使用fallback逻辑
-	return s.getCultivatePlanWithFallback(info, viewStateMap)
+	return s.getCultivatePlanWithFallback(ctx, info, viewStateMap)
 }
 
 // 精确匹配学院和专业代码获取培养方案
-func (s *Student) getCultivatePlanWithPreciseMatch(info *StudentDetail, viewStateMap map[string]string) (string, error) {
+func (s *Student) getCultivatePlanWithPreciseMatch(ctx context.Context, info *StudentDetail, viewStateMap map[string]string) (string, error) {
 	// 获取学院选择页面
-	initialDoc, err := s.GetWithIdentifier(constants.CultivatePlanURL)
+	initialDoc, err := s.GetWithIdentifierCtx(ctx, s.endpoints.CultivatePlanURL)
 	if err != nil {
 		return "", err
 	}
 
 	// 查找学院代码
-	collegeSelect := htmlquery.FindOne(initialDoc, `//select[@id="xymcdpl"]`)
-	if collegeSelect == nil {
-		return "", fmt.Errorf("college select not found")
+	p := s.parser()
+	colleges, err := p.PlanColleges(initialDoc)
+	if err != nil {
+		return "", err
 	}
 
 	collegeCode := ""
-	collegeOptions := htmlquery.Find(collegeSelect, ".//option")
-	for _, option := range collegeOptions {
-		optionText := htmlquery.InnerText(option)
-		optionValue := htmlquery.SelectAttr(option, "value")
-
+	for _, option := range colleges {
 		// 直接匹配学院名称
-		if optionText == info.College {
-			collegeCode = optionValue
+		if option.Text == info.College {
+			collegeCode = option.Value
 			break
 		}
 
 		// 处理学院改名的情况
-		if (strings.Contains(optionText, "计算机与大数据") || strings.Contains(optionText, "数学与统计")) &&
+		if (strings.Contains(option.Text, "计算机与大数据") || strings.Contains(option.Text, "数学与统计")) &&
 			strings.Contains(info.College, "数学与计算机") {
-			collegeCode = optionValue
+			collegeCode = option.Value
 			break
 		}
 	}
 
 	if collegeCode == "" {
-		return "", fmt.Errorf("college code not found for %s", info.College)
+		return "", errno.HTMLParseError.WithMessage("college code not found for " + info.College)
 	}
 
-	viewStateGenerator := htmlquery.SelectAttr(htmlquery.FindOne(initialDoc, `//*[@id="__VIEWSTATEGENERATOR"]`), "value")
+	viewStateGenerator := htmlquery.SelectAttr(htmlquery.FindOne(initialDoc, s.selectors.Current().XPath(selectors.ViewStateGenerator)), "value")
 
 	// 选择年级和学院后获取专业列表
-	majorListResp, err := s.PostWithIdentifier(constants.CultivatePlanURL, map[string]string{
+	majorListResp, err := s.PostWithIdentifierCtx(ctx, s.endpoints.CultivatePlanURL, map[string]string{
 		"__VIEWSTATE":                         viewStateMap["VIEWSTATE"],
 		"__EVENTVALIDATION":                   viewStateMap["EVENTVALIDATION"],
 		"__EVENTTARGET":                       "ctl00$njdpl",
@@ -108,44 +114,42 @@ func (s *Student) getCultivatePlanWithPreciseMatch(info *StudentDetail, viewStat
 	}
 
 	// 查找专业代码
-	majorSelect := htmlquery.FindOne(majorListResp, `//select[@id="zymcdpl"]`)
-	if majorSelect == nil {
-		return "", fmt.Errorf("major select not found")
+	majors, err := p.PlanMajors(majorListResp)
+	if err != nil {
+		return "", err
 	}
 
 	majorCode := ""
-	majorOptions := htmlquery.Find(majorSelect, ".//option")
-	for _, option := range majorOptions {
-		optionText := htmlquery.InnerText(option)
-		if optionText == info.Major {
-			majorCode = htmlquery.SelectAttr(option, "value")
+	for _, option := range majors {
+		if option.Text == info.Major {
+			majorCode = option.Value
 			break
 		}
 	}
 
 	if majorCode == "" {
-		return "", fmt.Errorf("major code not found for %s", info.Major)
+		return "", errno.HTMLParseError.WithMessage("major code not found for " + info.Major)
 	}
 
 	// 构造最终URL
 	finalURL := fmt.Sprintf("/pyfa/pyjh/pyfa_bzy.aspx?nj=%s&xyh=%s&zyh=%s&zylb=本专业&id=%s",
 		info.Grade, collegeCode, majorCode, s.Identifier)
 
-	return constants.JwchPrefix + finalURL, nil
+	return s.endpoints.JwchPrefix + finalURL, nil
 }
 
 // fallback逻辑：当精确匹配失败时使用
-func (s *Student) getCultivatePlanWithFallback(info *StudentDetail, viewStateMap map[string]string) (string, error) {
+func (s *Student) getCultivatePlanWithFallback(ctx context.Context, info *StudentDetail, viewStateMap map[string]string) (string, error) {
 	// 获取初始页面状态
-	initialDoc, err := s.GetWithIdentifier(constants.CultivatePlanURL)
+	initialDoc, err := s.GetWithIdentifierCtx(ctx, s.endpoints.CultivatePlanURL)
 	if err != nil {
 		return "", err
 	}
 
-	viewStateGenerator := htmlquery.SelectAttr(htmlquery.FindOne(initialDoc, `//*[@id="__VIEWSTATEGENERATOR"]`), "value")
+	viewStateGenerator := htmlquery.SelectAttr(htmlquery.FindOne(initialDoc, s.selectors.Current().XPath(selectors.ViewStateGenerator)), "value")
 
 	// 只选择年级，提交查询
-	res, err := s.PostWithIdentifier(constants.CultivatePlanURL,
+	res, err := s.PostWithIdentifierCtx(ctx, s.endpoints.CultivatePlanURL,
 		map[string]string{
 			"__VIEWSTATE":                         viewStateMap["VIEWSTATE"],
 			"__EVENTVALIDATION":                   viewStateMap["EVENTVALIDATION"],
@@ -162,13 +166,5 @@ func (s *Student) getCultivatePlanWithFallback(info *StudentDetail, viewStateMap
 	if err != nil {
 		return "", err
 	}
-	xpathExpr := fmt.Sprintf("//tr[td[matches(string(.), '^（.*?）%s$')]]/td/a[contains(@href, 'pyfa')]/@href", regexp.QuoteMeta(info.Major))
-	node := htmlquery.FindOne(res, xpathExpr)
-	if node == nil {
-		return "", fmt.Errorf("cultivate plan not found for major: %s", info.Major)
-	}
-
-	url := htmlquery.SelectAttr(node, "href")
-	formatUrl := constants.JwchPrefix + "/pyfa/pyjh/" + strings.TrimPrefix(strings.TrimSuffix(url, "')"), "javascript:pop1('")
-	return formatUrl, nil
+	return s.parser().PlanURL(res, info.Major, s.endpoints.JwchPrefix)
 }
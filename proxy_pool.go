@@ -0,0 +1,362 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/west2-online/jwch/constants"
+	"github.com/west2-online/jwch/errno"
+)
+
+// ProxyRotation 代理的轮换方式
+type ProxyRotation int
+
+const (
+	// ProxyPerStudent 每个 Student 固定使用一个代理，代理失效后才切换
+	ProxyPerStudent ProxyRotation = iota
+	// ProxyPerRequest 每个请求轮流使用池中的代理
+	ProxyPerRequest
+)
+
+// ProxyPool 代理池，定期检查代理是否可用，剔除失效的代理并从 ProxyProvider 补充
+// 同一个 ProxyPool 可以通过 WithProxyPool 在多个 Student 之间共享；导出的字段需要在使用前设置
+type ProxyPool struct {
+	CheckURL     string        // 健康检查访问的地址，默认为教务处首页
+	CheckTimeout time.Duration // 单次健康检查的超时时间
+	MaxFailures  int           // 请求连续失败多少次后剔除该代理
+	MinSize      int           // 可用代理少于该数量时从 provider 补充
+	Logger       *slog.Logger  // 日志输出，默认使用 slog.Default()
+
+	provider ProxyProvider
+
+	mu      sync.Mutex
+	entries []*proxyEntry
+	cursor  int
+
+	refreshMu sync.Mutex // 保证同一时间只有一个 goroutine 在补充代理
+}
+
+type proxyEntry struct {
+	url      *url.URL
+	failures int
+}
+
+// NewProxyPool 创建代理池，代理在第一次使用时才会从 provider 获取
+func NewProxyPool(provider ProxyProvider) *ProxyPool {
+	return &ProxyPool{
+		CheckURL:     constants.JwchPrefix,
+		CheckTimeout: 5 * time.Second,
+		MaxFailures:  2,
+		MinSize:      1,
+		provider:     provider,
+	}
+}
+
+// Len 返回池中可用的代理数
+func (p *ProxyPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// Proxies 返回池中可用的代理
+func (p *ProxyPool) Proxies() []*url.URL {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	proxies := make([]*url.URL, len(p.entries))
+	for i, e := range p.entries {
+		proxies[i] = e.url
+	}
+	return proxies
+}
+
+// Refresh 从 provider 重新获取代理，已在池中的代理保留原有的状态
+func (p *ProxyPool) Refresh(ctx context.Context) error {
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+	return p.refresh(ctx)
+}
+
+func (p *ProxyPool) refresh(ctx context.Context) error {
+	proxies, err := p.provider.Proxies(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	existing := make(map[string]*proxyEntry, len(p.entries))
+	for _, e := range p.entries {
+		existing[e.url.String()] = e
+	}
+	entries := make([]*proxyEntry, 0, len(proxies))
+	for _, u := range proxies {
+		if e, ok := existing[u.String()]; ok {
+			entries = append(entries, e)
+			delete(existing, u.String())
+			continue
+		}
+		entries = append(entries, &proxyEntry{url: u})
+	}
+	p.entries = entries
+	p.logger().DebugContext(ctx, "jwch: proxy pool refreshed", "size", len(entries))
+	return nil
+}
+
+// ensure 可用代理不足时补充，多个 goroutine 同时发现不足时只会补充一次
+func (p *ProxyPool) ensure(ctx context.Context) error {
+	if p.Len() >= max(p.MinSize, 1) {
+		return nil
+	}
+	p.refreshMu.Lock()
+	defer p.refreshMu.Unlock()
+	if p.Len() >= max(p.MinSize, 1) {
+		return nil
+	}
+	err := p.refresh(ctx)
+	if err != nil && p.Len() > 0 {
+		// 仍有可用的代理，补充失败不影响本次请求
+		p.logger().WarnContext(ctx, "jwch: refresh proxy pool failed", "error", err)
+		return nil
+	}
+	return err
+}
+
+// Next 轮流返回池中的代理，池为空时先从 provider 补充，仍然没有代理时返回 ProxyUnavailableError
+func (p *ProxyPool) Next(ctx context.Context) (*url.URL, error) {
+	if err := p.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.entries) == 0 {
+		return nil, errno.ProxyUnavailableError
+	}
+	e := p.entries[p.cursor%len(p.entries)]
+	p.cursor++
+	return e.url, nil
+}
+
+// ReportSuccess 记录通过该代理的请求成功
+func (p *ProxyPool) ReportSuccess(u *url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e := p.find(u); e != nil {
+		e.failures = 0
+	}
+}
+
+// ReportFailure 记录通过该代理的请求失败，连续失败 MaxFailures 次后剔除该代理
+func (p *ProxyPool) ReportFailure(u *url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.find(u)
+	if e == nil {
+		return
+	}
+	e.failures++
+	if e.failures >= max(p.MaxFailures, 1) {
+		p.evict(u)
+	}
+}
+
+// Evict 立即从池中剔除代理
+func (p *ProxyPool) Evict(u *url.URL) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.evict(u)
+}
+
+func (p *ProxyPool) find(u *url.URL) *proxyEntry {
+	for _, e := range p.entries {
+		if e.url.String() == u.String() {
+			return e
+		}
+	}
+	return nil
+}
+
+func (p *ProxyPool) evict(u *url.URL) {
+	for i, e := range p.entries {
+		if e.url.String() == u.String() {
+			p.entries = append(p.entries[:i:i], p.entries[i+1:]...)
+			p.logger().Info("jwch: proxy evicted", "proxy", u.Redacted())
+			return
+		}
+	}
+}
+
+// HealthCheck 通过每个代理访问 CheckURL，剔除不可用的代理，之后代理不足时从 provider 补充
+func (p *ProxyPool) HealthCheck(ctx context.Context) error {
+	proxies := p.Proxies()
+	var wg sync.WaitGroup
+	for _, u := range proxies {
+		wg.Add(1)
+		go func(u *url.URL) {
+			defer wg.Done()
+			if err := p.check(ctx, u); err != nil {
+				if contextError(ctx) != nil {
+					return
+				}
+				p.logger().DebugContext(ctx, "jwch: proxy health check failed", "proxy", u.Redacted(), "error", err)
+				p.Evict(u)
+			}
+		}(u)
+	}
+	wg.Wait()
+
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	return p.ensure(ctx)
+}
+
+// Start 在后台每隔 interval 做一次健康检查，ctx 被取消后停止
+func (p *ProxyPool) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.HealthCheck(ctx); err != nil && contextError(ctx) == nil {
+					p.logger().WarnContext(ctx, "jwch: proxy health check failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// check 通过代理访问 CheckURL，5xx（代理无法连接上游时通常返回 502）视为失败
+func (p *ProxyPool) check(ctx context.Context, u *url.URL) error {
+	transport := &http.Transport{
+		Proxy:           http.ProxyURL(u),
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   p.CheckTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.CheckURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return errno.ProxyUnavailableError.WithMessage("proxy health check returned " + resp.Status)
+	}
+	return nil
+}
+
+func (p *ProxyPool) logger() *slog.Logger {
+	if p.Logger != nil {
+		return p.Logger
+	}
+	return slog.Default()
+}
+
+type proxyKey struct{}
+
+// proxyFromContext 作为 http.Transport.Proxy，使用 proxyTransport 为请求选择的代理
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	u, _ := req.Context().Value(proxyKey{}).(*url.URL)
+	return u, nil
+}
+
+// proxyTransport 为每个请求从代理池中选择代理，并把请求结果反馈给代理池
+type proxyTransport struct {
+	pool     *ProxyPool
+	next     http.RoundTripper
+	rotation ProxyRotation
+	direct   bool // 没有可用的代理时直连，而不是返回 ProxyUnavailableError
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	current *url.URL // ProxyPerStudent 时当前使用的代理
+}
+
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	u, err := t.pick(ctx)
+	if err != nil {
+		if !t.direct || ctx.Err() != nil {
+			return nil, err
+		}
+		t.logger.WarnContext(ctx, "jwch: no proxy available, connecting directly", "error", err)
+		return t.next.RoundTrip(req)
+	}
+
+	resp, err := t.next.RoundTrip(req.WithContext(context.WithValue(ctx, proxyKey{}, u)))
+	switch {
+	case err == nil:
+		t.pool.ReportSuccess(u)
+	case ctx.Err() == nil:
+		t.pool.ReportFailure(u)
+		t.mu.Lock()
+		if t.current == u {
+			t.current = nil
+		}
+		t.mu.Unlock()
+	}
+	return resp, err
+}
+
+func (t *proxyTransport) pick(ctx context.Context) (*url.URL, error) {
+	if t.rotation == ProxyPerRequest {
+		return t.pool.Next(ctx)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// 固定的代理已被剔除（例如健康检查失败）时也需要切换
+	if t.current != nil && t.pool.contains(t.current) {
+		return t.current, nil
+	}
+	u, err := t.pool.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.current = u
+	return u, nil
+}
+
+func (p *ProxyPool) contains(u *url.URL) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.find(u) != nil
+}
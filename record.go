@@ -0,0 +1,526 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// Redacted 录制的请求和传给 hook 的 URL 中替换敏感信息使用的占位符
+// 回放时学号也是 Redacted，需要通过 WithUser(Redacted, "") 让 CheckSession 的学号校验通过
+const Redacted = "REDACTED"
+
+// 个人信息页面中需要脱敏的字段
+var personalSelectors = []selectors.Name{
+	selectors.UserStudentID, selectors.UserName, selectors.UserBirthday, selectors.UserSex,
+	selectors.UserPhone, selectors.UserEmail, selectors.UserCollege, selectors.UserGrade,
+	selectors.UserStatusChanges, selectors.UserMajor, selectors.UserCounselor, selectors.UserExamineeCategory,
+	selectors.UserNationality, selectors.UserCountry, selectors.UserPoliticalStatus, selectors.UserSource,
+}
+
+// 这些字段的值可能出现在其他页面中（例如页头的学号和姓名），会在全部记录中替换
+var personalSecrets = []selectors.Name{
+	selectors.UserStudentID, selectors.UserName, selectors.UserBirthday, selectors.UserPhone, selectors.UserEmail,
+}
+
+// 过短的值（例如单个数字）直接全文替换会误伤页面内容，只在所在的参数、cookie 中替换
+const minSecretLength = 4
+
+// Recorder 记录 Student 实际发出的每个请求（包括重试）和收到的响应，通过 WithRecorder 启用
+// 记录时替换 URL 和表单中的学号、密码哈希、Identifier 等参数，cookie 的值，以及个人信息页面中的字段；
+// 写出时再将这些值在全部记录中替换为 Redacted
+type Recorder struct {
+	mu      sync.Mutex
+	entries []harEntry
+	secrets map[string]struct{} // 需要在全部记录中替换的原始值
+}
+
+// NewRecorder 创建空的 Recorder，可以在多个 Student 之间共享
+func NewRecorder() *Recorder {
+	return &Recorder{secrets: make(map[string]struct{})}
+}
+
+// Len 返回已经记录的请求数
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// WriteHAR 将记录以 HAR 1.2 格式写入 w，可以用浏览器的开发者工具打开，或通过 NewReplayTransport 回放
+func (r *Recorder) WriteHAR(w io.Writer) error {
+	r.mu.Lock()
+	entries := slices.Clone(r.entries)
+	secrets := secretVariants(r.secrets)
+	r.mu.Unlock()
+
+	for i := range entries {
+		entries[i] = redactEntry(entries[i], secrets)
+	}
+	har := harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "jwch", Version: "1"},
+		Entries: entries,
+	}}
+	if har.Log.Entries == nil {
+		har.Log.Entries = []harEntry{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(har); err != nil {
+		return errno.ServiceInternalError.WithErr(err)
+	}
+	return nil
+}
+
+// SaveHAR 将记录以 HAR 格式保存到 path，见 WriteHAR
+func (r *Recorder) SaveHAR(path string) error {
+	var buf bytes.Buffer
+	if err := r.WriteHAR(&buf); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return errno.ServiceInternalError.WithErr(err)
+	}
+	return nil
+}
+
+// Reset 清空已有的记录
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+	r.secrets = make(map[string]struct{})
+}
+
+func (r *Recorder) add(entry harEntry, secrets []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength && secret != Redacted {
+			r.secrets[secret] = struct{}{}
+		}
+	}
+}
+
+// transport 返回记录请求的 RoundTripper，registry 用于定位个人信息页面中的字段
+func (r *Recorder) transport(next http.RoundTripper, registry *selectors.Registry) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &recordTransport{recorder: r, registry: registry, next: next}
+}
+
+// recordTransport 位于最内层，记录每次实际发出的请求
+type recordTransport struct {
+	recorder *Recorder
+	registry *selectors.Registry
+	next     http.RoundTripper
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	var secrets []string
+	entry := harEntry{
+		StartedDateTime: time.Now(),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         redactURL(req.URL),
+			HTTPVersion: "HTTP/1.1",
+			Headers:     redactHeader(req.Header, &secrets),
+			QueryString: harQuery(req.URL),
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    int64(len(reqBody)),
+		},
+		Cache: struct{}{},
+	}
+	secrets = append(secrets, sensitiveValues(req.URL.Query())...)
+	if reqBody != nil {
+		contentType := req.Header.Get("Content-Type")
+		entry.Request.PostData = newHARPostData(contentType, redactForm(contentType, reqBody, &secrets))
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		entry.Time = msSince(entry.StartedDateTime)
+		entry.Error = err.Error()
+		entry.Response = harResponse{Headers: []harNameValue{}, Cookies: []harNameValue{}, HeadersSize: -1, BodySize: -1}
+		t.recorder.add(entry, secrets)
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	contentType := resp.Header.Get("Content-Type")
+	recorded := body
+	if strings.Contains(contentType, "html") {
+		recorded = redactPersonal(body, t.registry.Current(), &secrets)
+	}
+	entry.Time = msSince(entry.StartedDateTime)
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: "HTTP/1.1",
+		Headers:     redactHeader(resp.Header, &secrets),
+		Cookies:     []harNameValue{},
+		Content:     newHARContent(contentType, recorded),
+		RedirectURL: redactLocation(resp.Header.Get("Location"), &secrets),
+		HeadersSize: -1,
+		BodySize:    int64(len(recorded)),
+	}
+	entry.Timings = harTimings{Wait: entry.Time}
+	t.recorder.add(entry, secrets)
+	return resp, nil
+}
+
+// redactHeader 复制请求头或响应头，替换 cookie 的值和重定向地址中的敏感参数
+func redactHeader(header http.Header, secrets *[]string) []harNameValue {
+	res := []harNameValue{}
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			switch http.CanonicalHeaderKey(name) {
+			case "Cookie":
+				cookies, err := http.ParseCookie(value)
+				if err != nil {
+					value = Redacted
+					break
+				}
+				parts := make([]string, 0, len(cookies))
+				for _, c := range cookies {
+					*secrets = append(*secrets, c.Value)
+					parts = append(parts, c.Name+"="+Redacted)
+				}
+				value = strings.Join(parts, "; ")
+			case "Set-Cookie":
+				c, err := http.ParseSetCookie(value)
+				if err != nil {
+					value = Redacted
+					break
+				}
+				*secrets = append(*secrets, c.Value)
+				c.Value = Redacted
+				value = c.String()
+			case "Location":
+				value = redactLocation(value, secrets)
+			case "Authorization", "Proxy-Authorization":
+				value = Redacted
+			}
+			res = append(res, harNameValue{Name: name, Value: value})
+		}
+	}
+	return res
+}
+
+// redactLocation 替换重定向地址中的 id、token 等参数
+func redactLocation(location string, secrets *[]string) string {
+	if location == "" {
+		return ""
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return Redacted
+	}
+	*secrets = append(*secrets, sensitiveValues(u.Query())...)
+	return redactURL(u)
+}
+
+// redactForm 替换表单中的学号、密码哈希等字段，非表单的请求体原样返回
+func redactForm(contentType string, body []byte, secrets *[]string) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "application/x-www-form-urlencoded" {
+		return body
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return body
+	}
+	*secrets = append(*secrets, sensitiveValues(form)...)
+	for key := range form {
+		if slices.Contains(sensitiveQueryParams, key) {
+			form.Set(key, Redacted)
+		}
+	}
+	return []byte(form.Encode())
+}
+
+// sensitiveValues 返回参数中敏感字段的值
+func sensitiveValues(values url.Values) []string {
+	var res []string
+	for key, vs := range values {
+		if slices.Contains(sensitiveQueryParams, key) {
+			res = append(res, vs...)
+		}
+	}
+	return res
+}
+
+// redactPersonal 将个人信息页面中的字段替换为 Redacted，其他页面原样返回
+// 学号、姓名等可能出现在其他页面中的值加入 secrets
+func redactPersonal(body []byte, sel *selectors.Set, secrets *[]string) []byte {
+	doc, err := htmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return body
+	}
+	found := false
+	for _, name := range personalSelectors {
+		for _, node := range htmlquery.Find(doc, sel.XPath(name)) {
+			if slices.Contains(personalSecrets, name) {
+				*secrets = append(*secrets, strings.TrimSpace(htmlquery.InnerText(node)))
+			}
+			for child := node.FirstChild; child != nil; child = node.FirstChild {
+				node.RemoveChild(child)
+			}
+			node.AppendChild(&html.Node{Type: html.TextNode, Data: Redacted})
+			found = true
+		}
+	}
+	if !found {
+		return body
+	}
+	var buf bytes.Buffer
+	if err = html.Render(&buf, doc); err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
+
+// secretVariants 返回需要替换的值及其 GB18030 和 URL 编码形式，长的在前，避免只替换了一部分
+func secretVariants(secrets map[string]struct{}) [][]byte {
+	set := make(map[string]struct{}, len(secrets)*2)
+	for secret := range secrets {
+		set[secret] = struct{}{}
+		set[url.QueryEscape(secret)] = struct{}{}
+		if utf8.ValidString(secret) {
+			if gb, err := simplifiedchinese.GB18030.NewEncoder().String(secret); err == nil {
+				set[gb] = struct{}{}
+			}
+		} else if decoded, err := simplifiedchinese.GB18030.NewDecoder().String(secret); err == nil {
+			set[decoded] = struct{}{}
+		}
+	}
+	res := make([][]byte, 0, len(set))
+	for secret := range set {
+		res = append(res, []byte(secret))
+	}
+	slices.SortFunc(res, func(a, b []byte) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return bytes.Compare(a, b)
+	})
+	return res
+}
+
+// redactEntry 在记录的全部文本中替换 secrets
+func redactEntry(entry harEntry, secrets [][]byte) harEntry {
+	replace := func(s string) string {
+		b := []byte(s)
+		for _, secret := range secrets {
+			b = bytes.ReplaceAll(b, secret, []byte(Redacted))
+		}
+		return string(b)
+	}
+	replaceAll := func(values []harNameValue) []harNameValue {
+		res := make([]harNameValue, len(values))
+		for i, v := range values {
+			res[i] = harNameValue{Name: v.Name, Value: replace(v.Value)}
+		}
+		return res
+	}
+
+	entry.Request.URL = replace(entry.Request.URL)
+	entry.Request.Headers = replaceAll(entry.Request.Headers)
+	entry.Request.QueryString = replaceAll(entry.Request.QueryString)
+	if entry.Request.PostData != nil {
+		postData := *entry.Request.PostData
+		postData.Text = replaceEncoded(postData.Text, postData.Encoding, replace)
+		entry.Request.PostData = &postData
+	}
+	entry.Response.Headers = replaceAll(entry.Response.Headers)
+	entry.Response.RedirectURL = replace(entry.Response.RedirectURL)
+	entry.Response.Content.Text = replaceEncoded(entry.Response.Content.Text, entry.Response.Content.Encoding, replace)
+	if body, err := entry.Response.Content.decode(); err == nil {
+		entry.Response.Content.Size = len(body)
+		entry.Response.BodySize = int64(len(body))
+	}
+	entry.Error = replace(entry.Error)
+	return entry
+}
+
+// replaceEncoded 对 base64 编码的内容先解码再替换
+func replaceEncoded(text, encoding string, replace func(string) string) string {
+	if encoding != "base64" {
+		return replace(text)
+	}
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return text
+	}
+	return base64.StdEncoding.EncodeToString([]byte(replace(string(data))))
+}
+
+func msSince(start time.Time) float64 {
+	return float64(time.Since(start)) / float64(time.Millisecond)
+}
+
+// HAR 1.2 的子集，见 http://www.softwareishard.com/blog/har-12-spec/
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // 毫秒
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"` // 请求没有收到响应时的错误
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"_encoding,omitempty"` // 请求体不是 UTF-8 时为 base64
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"` // 响应体不是 UTF-8（例如 GB2312 页面、验证码图片）时为 base64
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func harQuery(u *url.URL) []harNameValue {
+	res := []harNameValue{}
+	query := u.Query()
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		for _, value := range query[key] {
+			if slices.Contains(sensitiveQueryParams, key) {
+				value = Redacted
+			}
+			res = append(res, harNameValue{Name: key, Value: value})
+		}
+	}
+	return res
+}
+
+func newHARPostData(contentType string, body []byte) *harPostData {
+	if utf8.Valid(body) {
+		return &harPostData{MimeType: contentType, Text: string(body)}
+	}
+	return &harPostData{MimeType: contentType, Text: base64.StdEncoding.EncodeToString(body), Encoding: "base64"}
+}
+
+func newHARContent(contentType string, body []byte) harContent {
+	if utf8.Valid(body) {
+		return harContent{Size: len(body), MimeType: contentType, Text: string(body)}
+	}
+	return harContent{Size: len(body), MimeType: contentType, Text: base64.StdEncoding.EncodeToString(body), Encoding: "base64"}
+}
+
+// decode 返回原始的内容
+func (c harContent) decode() ([]byte, error) {
+	if c.Encoding != "base64" {
+		return []byte(c.Text), nil
+	}
+	return base64.StdEncoding.DecodeString(c.Text)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"errors"
+	"maps"
+
+	"github.com/antchfx/htmlquery"
+
+	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/selectors"
+)
+
+// ASP.NET 回发时需要与页面保持一致的隐藏字段及其选择器
+var viewStateFields = []struct {
+	field    string
+	selector selectors.Name
+}{
+	{"__VIEWSTATE", selectors.ViewState},
+	{"__EVENTVALIDATION", selectors.EventValidation},
+	{"__VIEWSTATEGENERATOR", selectors.ViewStateGenerator},
+}
+
+// shouldRelogin 判断请求失败后是否需要自动重新登录
+func (s *Student) shouldRelogin(err error) bool {
+	return s.autoRelogin && errors.Is(err, errno.CookieError) && s.ID != "" && s.Password != ""
+}
+
+// reloginReplayKey 标记 ctx 所在的操作会在会话过期时从头重新执行，其中的单个请求不再各自重放
+type reloginReplayKey struct{}
+
+// shouldReplayRequest 判断单个请求失败后是否需要重新登录并重放该请求
+func (s *Student) shouldReplayRequest(ctx context.Context, err error) bool {
+	replayed, _ := ctx.Value(reloginReplayKey{}).(bool)
+	return !replayed && s.shouldRelogin(err)
+}
+
+// withRelogin 执行由多步回发组成的操作，会话过期时重新登录后从头执行一次
+// 后一步回发需要带上前一步回发返回的 VIEWSTATE，只重放最后一次回发会带上错误的页面状态
+func withRelogin[T any](ctx context.Context, s *Student, fetch func(context.Context) (T, error)) (T, error) {
+	s.mu.RLock()
+	generation := s.generation
+	s.mu.RUnlock()
+
+	ctx = context.WithValue(ctx, reloginReplayKey{}, true)
+	value, err := fetch(ctx)
+	if !s.shouldRelogin(err) {
+		return value, err
+	}
+	if err = s.relogin(ctx, generation); err != nil {
+		var zero T
+		return zero, err
+	}
+	return fetch(ctx)
+}
+
+// relogin 重新登录，generation 为请求失败时登录数据的版本
+// 多个 goroutine 同时发现会话过期时只会登录一次，其余的直接使用新的登录数据
+func (s *Student) relogin(ctx context.Context, generation uint64) error {
+	s.loginMu.Lock()
+	defer s.loginMu.Unlock()
+
+	s.mu.RLock()
+	current := s.generation
+	s.mu.RUnlock()
+	if current != generation {
+		return nil
+	}
+
+	s.logger.InfoContext(ctx, "jwch: session expired, logging in again", "student", s.ID)
+	return s.LoginCtx(ctx)
+}
+
+// refreshViewState 重新获取页面，把表单中的 VIEWSTATE 等隐藏字段替换为新会话下的值
+// 只适用于直接在页面上提交的表单，多步回发的操作见 withRelogin
+// 表单中没有 __VIEWSTATE 时原样返回
+func (s *Student) refreshViewState(ctx context.Context, url string, formData map[string]string) (map[string]string, error) {
+	if _, ok := formData["__VIEWSTATE"]; !ok {
+		return formData, nil
+	}
+
+	doc, _, err := s.getWithIdentifier(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	sel := s.selectors.Current()
+	refreshed := maps.Clone(formData)
+	for _, f := range viewStateFields {
+		if _, ok := refreshed[f.field]; !ok {
+			continue
+		}
+		node := htmlquery.FindOne(doc, sel.XPath(f.selector))
+		if node == nil {
+			return nil, errno.HTMLParseError.WithMessage("refresh " + f.field + " failed")
+		}
+		refreshed[f.field] = htmlquery.SelectAttr(node, "value")
+	}
+	return refreshed, nil
+}
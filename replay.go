@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+// 每次请求都会变化的表单字段，回放时不参与匹配
+var volatileFormFields = []string{"__VIEWSTATE", "__EVENTVALIDATION", "__VIEWSTATEGENERATOR", "Verifycode"}
+
+// ReplayTransport 使用 Recorder 录制的 HAR 文件响应请求，不访问网络，通过 WithTransport 使用
+// 请求按方法、URL 和表单匹配，id、token 等脱敏的参数以及 VIEWSTATE 不参与匹配
+// 同一个请求录制了多次时按录制的顺序返回，用完后一直返回最后一次的响应
+type ReplayTransport struct {
+	mu      sync.Mutex
+	entries map[string][]harEntry
+	served  map[string]int
+}
+
+// NewReplayTransport 读取 HAR 文件，HAR 格式不正确时返回 ParamError
+func NewReplayTransport(r io.Reader) (*ReplayTransport, error) {
+	var har harFile
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return nil, errno.ParamError.WithMessage("invalid HAR archive").WithErr(err)
+	}
+
+	t := &ReplayTransport{entries: make(map[string][]harEntry), served: make(map[string]int)}
+	for i, entry := range har.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			return nil, errno.ParamError.WithMessage("invalid url in HAR entry " + strconv.Itoa(i)).WithErr(err)
+		}
+		var body []byte
+		contentType := ""
+		if entry.Request.PostData != nil {
+			contentType = entry.Request.PostData.MimeType
+			body, err = harContent{Text: entry.Request.PostData.Text, Encoding: entry.Request.PostData.Encoding}.decode()
+			if err != nil {
+				return nil, errno.ParamError.WithMessage("invalid post data in HAR entry " + strconv.Itoa(i)).WithErr(err)
+			}
+		}
+		key := replayKey(entry.Request.Method, u, contentType, body)
+		t.entries[key] = append(t.entries[key], entry)
+	}
+	return t, nil
+}
+
+// LoadReplayTransport 读取 path 处的 HAR 文件，见 NewReplayTransport
+func LoadReplayTransport(path string) (*ReplayTransport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errno.ParamError.WithErr(err)
+	}
+	defer func() { _ = f.Close() }()
+	return NewReplayTransport(f)
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	key := replayKey(req.Method, req.URL, req.Header.Get("Content-Type"), body)
+	t.mu.Lock()
+	entries := t.entries[key]
+	i := t.served[key]
+	if i < len(entries)-1 {
+		t.served[key]++
+	}
+	t.mu.Unlock()
+	if len(entries) == 0 {
+		return nil, errno.HTTPQueryError.WithMessage("no recorded response for " + req.Method + " " + redactURL(req.URL))
+	}
+
+	entry := entries[min(i, len(entries)-1)]
+	if entry.Error != "" {
+		return nil, errno.HTTPQueryError.WithMessage("recorded error: " + entry.Error)
+	}
+	content, err := entry.Response.Content.decode()
+	if err != nil {
+		return nil, errno.HTTPQueryError.WithMessage("invalid recorded response").WithErr(err)
+	}
+
+	header := make(http.Header)
+	for _, h := range entry.Response.Headers {
+		// 脱敏后响应体的长度可能变化
+		if http.CanonicalHeaderKey(h.Name) == "Content-Length" {
+			continue
+		}
+		header.Add(h.Name, h.Value)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(entry.Response.Status) + " " + entry.Response.StatusText,
+		StatusCode:    entry.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(content)),
+		ContentLength: int64(len(content)),
+		Request:       req,
+	}, nil
+}
+
+// replayKey 返回用于匹配请求的键，忽略脱敏的参数和每次都会变化的表单字段
+func replayKey(method string, u *url.URL, contentType string, body []byte) string {
+	var b strings.Builder
+	b.WriteString(method + " " + u.Scheme + "://" + u.Host + u.Path)
+	if query := matchable(u.Query()); len(query) > 0 {
+		b.WriteString("?" + query.Encode())
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			b.WriteString("\n" + matchable(form).Encode())
+		}
+	} else if len(body) > 0 {
+		b.WriteString("\n")
+		b.Write(body)
+	}
+	return b.String()
+}
+
+// matchable 去掉参数中脱敏和每次都会变化的字段
+func matchable(values url.Values) url.Values {
+	res := make(url.Values, len(values))
+	for key, vs := range values {
+		if slices.Contains(sensitiveQueryParams, key) || slices.Contains(volatileFormFields, key) {
+			continue
+		}
+		res[key] = vs
+	}
+	return res
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+// RetryPolicy 请求失败时的重试策略
+// 只有 GET、HEAD 等安全的请求会被重试，POST 提交（选课、评议、登录等）永远不会被重试
+type RetryPolicy struct {
+	MaxAttempts int           // 最多尝试的次数（包括第一次），<= 1 表示不重试
+	BaseDelay   time.Duration // 第一次重试前的等待时长，之后每次翻倍
+	MaxDelay    time.Duration // 单次等待的最长时长，<= 0 表示不限制
+	Jitter      float64       // 随机抖动的比例（0-1），实际等待时长在 [delay*(1-Jitter), delay] 之间
+
+	// RetryOn 判断是否需要重试，resp 和 err 中恰好有一个不为 nil，为 nil 时使用 DefaultRetryOn
+	RetryOn func(resp *http.Response, err error) bool
+}
+
+// DefaultRetryPolicy 返回默认的重试策略：最多 3 次，等待 200ms、400ms，抖动 50%
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+		Jitter:      0.5,
+	}
+}
+
+// DefaultRetryOn 网络错误（超时、连接被重置等）和 502、503、504 时重试，ctx 被取消时不重试
+func DefaultRetryOn(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RetryError 重试次数用尽后返回的错误，Attempts 为实际尝试的次数
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+type attemptsKey struct{}
+
+// withAttempts 返回记录实际尝试次数的 ctx，重试的 transport 会在返回前写入尝试次数，见 statusError
+func withAttempts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, new(int))
+}
+
+// attemptsFrom 返回 ctx 中记录的尝试次数，没有经过重试时为 0
+func attemptsFrom(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptsKey{}).(*int); ok {
+		return *n
+	}
+	return 0
+}
+
+type noRetryKey struct{}
+
+// ContextWithoutRetry 返回不会被重试的 ctx，用于有副作用的 GET 请求
+func ContextWithoutRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+func retryDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noRetryKey{}).(bool)
+	return disabled
+}
+
+// Transport 返回按照该策略重试的 RoundTripper，next 为 nil 时使用 http.DefaultTransport
+func (p RetryPolicy) Transport(next http.RoundTripper) http.RoundTripper {
+	return p.transport(next, slog.Default())
+}
+
+func (p RetryPolicy) transport(next http.RoundTripper, logger *slog.Logger) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if p.RetryOn == nil {
+		p.RetryOn = DefaultRetryOn
+	}
+	return &retryTransport{policy: p, next: next, logger: logger}
+}
+
+// delay 返回第 attempt 次重试前的等待时长
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	// 移位溢出时使用最长等待时长
+	if d>>(attempt-1) != p.BaseDelay || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(rand.Float64() * min(p.Jitter, 1) * float64(d))
+	}
+	return d
+}
+
+type retryTransport struct {
+	policy RetryPolicy
+	next   http.RoundTripper
+	logger *slog.Logger
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !retryable(req) || t.policy.MaxAttempts <= 1 || retryDisabled(ctx) {
+		return t.next.RoundTrip(req)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.policy.MaxAttempts || !t.policy.RetryOn(resp, err) {
+			if attempt > 1 {
+				if n, ok := ctx.Value(attemptsKey{}).(*int); ok {
+					// 响应没有错误可以包装，尝试次数记录在请求的 ctx 中，见 statusError
+					*n = attempt
+				}
+				if err != nil {
+					err = &RetryError{Attempts: attempt, Err: err}
+				}
+			}
+			return resp, err
+		}
+		if resp != nil {
+			// 丢弃本次的响应，连接可以被复用
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		delay := t.policy.delay(attempt)
+		t.logger.DebugContext(ctx, "jwch: retrying request", "url", req.URL.Redacted(), "attempt", attempt, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// retryable 只重试安全的请求，请求体无法重放时不重试
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// networkError 将请求失败的错误转换为 JwchNetworkError，并记录重试的次数
+func networkError(err error) error {
+	e := errno.JwchNetworkError.WithErr(err)
+	var retryErr *RetryError
+	if errors.As(err, &retryErr) {
+		e = e.WithAttempts(retryErr.Attempts)
+	}
+	return e
+}
+
+// statusError 将 5xx 响应转换为 JwchNetworkError，并记录重试的次数，其他响应返回 nil
+func statusError(resp *resty.Response) error {
+	if resp.StatusCode() < http.StatusInternalServerError {
+		return nil
+	}
+	e := errno.JwchNetworkError.WithMessage("jwch returned " + resp.Status())
+	if attempts := attemptsFrom(resp.Request.Context()); attempts > 0 {
+		e = e.WithAttempts(attempts)
+	}
+	return e
+}
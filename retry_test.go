@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// statusTransport 总是返回 code 状态码的响应
+type statusTransport struct {
+	code  int
+	calls int
+}
+
+func (s *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.calls++
+	return &http.Response{
+		StatusCode: s.code,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 64: 300 * time.Millisecond} {
+		if got := policy.delay(attempt); got != want {
+			t.Errorf("delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := policy.delay(2); d < 100*time.Millisecond || d > 200*time.Millisecond {
+			t.Fatalf("jittered delay %v out of range", d)
+		}
+	}
+}
+
+func TestDefaultRetryOn(t *testing.T) {
+	if !DefaultRetryOn(nil, errors.New("connection reset by peer")) {
+		t.Errorf("network error should be retried")
+	}
+	if DefaultRetryOn(nil, context.Canceled) || DefaultRetryOn(nil, context.DeadlineExceeded) {
+		t.Errorf("canceled request should not be retried")
+	}
+	for code, want := range map[int]bool{http.StatusOK: false, http.StatusInternalServerError: false, http.StatusServiceUnavailable: true} {
+		if got := DefaultRetryOn(&http.Response{StatusCode: code}, nil); got != want {
+			t.Errorf("DefaultRetryOn(%d) = %t, want %t", code, got, want)
+		}
+	}
+}
+
+// 尝试次数记录在请求的 ctx 中，不会写入响应头
+func TestRetryAttempts(t *testing.T) {
+	next := &statusTransport{code: http.StatusServiceUnavailable}
+	policy := RetryPolicy{MaxAttempts: 3}
+	ctx := withAttempts(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://jwch.fzu.edu.cn/", nil)
+	resp, err := policy.Transport(next).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if next.calls != 3 || attemptsFrom(ctx) != 3 {
+		t.Errorf("expected 3 attempts, got %d calls and %d recorded", next.calls, attemptsFrom(ctx))
+	}
+	if !reflect.DeepEqual(resp.Header, http.Header{"Content-Type": {"text/html"}}) {
+		t.Errorf("unexpected response header: %v", resp.Header)
+	}
+
+	// POST 请求不会被重试
+	next.calls = 0
+	ctx = withAttempts(context.Background())
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, "http://jwch.fzu.edu.cn/", strings.NewReader("a=1"))
+	if resp, err = policy.Transport(next).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if next.calls != 1 || attemptsFrom(ctx) != 0 {
+		t.Errorf("expected a single attempt, got %d calls and %d recorded", next.calls, attemptsFrom(ctx))
+	}
+}
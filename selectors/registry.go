@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectors
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/antchfx/xpath"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+// Set 一组完整的选择器和表格列映射，创建后不应再修改
+// 序列化结果可以直接作为覆盖文档的模板
+type Set struct {
+	XPaths map[Name]string             `json:"xpath" yaml:"xpath"`
+	Tables map[Table]map[string]string `json:"columns" yaml:"columns"` // 表格 -> 字段 -> 表头文字
+}
+
+// Default 返回内置的默认选择器
+func Default() *Set {
+	set := &Set{
+		XPaths: maps.Clone(defaultXPath),
+		Tables: make(map[Table]map[string]string, len(defaultColumns)),
+	}
+	for table, columns := range defaultColumns {
+		set.Tables[table] = maps.Clone(columns)
+	}
+	return set
+}
+
+// Parse 解析 JSON 文档，文档中的选择器和列映射覆盖在默认值之上，未出现的保持默认
+func Parse(data []byte) (*Set, error) {
+	return ParseWith(data, json.Unmarshal)
+}
+
+// ParseWith 同 Parse，使用 unmarshal 解析文档，例如传入 yaml.Unmarshal 以支持 YAML
+func ParseWith(data []byte, unmarshal func([]byte, any) error) (*Set, error) {
+	var override Set
+	if err := unmarshal(data, &override); err != nil {
+		return nil, errno.ParamError.WithMessage("invalid selectors document").WithErr(err)
+	}
+
+	set := Default()
+	for name, expr := range override.XPaths {
+		if _, ok := set.XPaths[name]; !ok {
+			return nil, errno.ParamError.WithMessage(fmt.Sprintf("unknown selector %q", name))
+		}
+		set.XPaths[name] = expr
+	}
+	for table, columns := range override.Tables {
+		if _, ok := set.Tables[table]; !ok {
+			return nil, errno.ParamError.WithMessage(fmt.Sprintf("unknown table %q", table))
+		}
+		for field, header := range columns {
+			if _, ok := set.Tables[table][field]; !ok {
+				return nil, errno.ParamError.WithMessage(fmt.Sprintf("unknown column %q in table %q", field, table))
+			}
+			set.Tables[table][field] = header
+		}
+	}
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Validate 检查全部选择器都存在且能够编译，表格的每一列都有表头且互不相同
+func (s *Set) Validate() error {
+	for _, name := range slices.Sorted(maps.Keys(defaultXPath)) {
+		expr := strings.TrimSpace(s.XPaths[name])
+		if expr == "" {
+			return errno.ParamError.WithMessage(fmt.Sprintf("selector %q is empty", name))
+		}
+		if _, err := xpath.Compile(expr); err != nil {
+			return errno.ParamError.WithMessage(fmt.Sprintf("selector %q is invalid", name)).WithErr(err)
+		}
+	}
+	for _, table := range slices.Sorted(maps.Keys(defaultColumns)) {
+		headers := make(map[string]string, len(defaultColumns[table]))
+		for _, field := range slices.Sorted(maps.Keys(defaultColumns[table])) {
+			header := HeaderText(s.Tables[table][field])
+			if header == "" {
+				return errno.ParamError.WithMessage(fmt.Sprintf("column %q in table %q has no header", field, table))
+			}
+			if other, ok := headers[header]; ok {
+				return errno.ParamError.WithMessage(fmt.Sprintf("columns %q and %q in table %q share header %q", other, field, table, header))
+			}
+			headers[header] = field
+		}
+	}
+	return nil
+}
+
+// XPath 返回名称对应的选择器
+func (s *Set) XPath(name Name) string {
+	return s.XPaths[name]
+}
+
+// Columns 返回表格中字段到表头文字的映射，调用方不应修改
+func (s *Set) Columns(table Table) map[string]string {
+	return s.Tables[table]
+}
+
+// HeaderText 去除表头中的全部空白字符（包括 &nbsp;），用于比较表头
+func HeaderText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// Registry 当前生效的选择器，可以在运行时并发安全地整体替换
+type Registry struct {
+	set atomic.Pointer[Set]
+}
+
+// DefaultRegistry 未通过 WithSelectors 指定时 Student 使用的选择器
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry 创建使用默认选择器的 Registry
+func NewRegistry() *Registry {
+	r := &Registry{}
+	r.set.Store(Default())
+	return r
+}
+
+// Current 返回当前生效的选择器，一次解析应只取一次，避免中途被替换
+func (r *Registry) Current() *Set {
+	return r.set.Load()
+}
+
+// Store 校验后替换当前的选择器，校验失败时保持原样
+func (r *Registry) Store(set *Set) error {
+	if err := set.Validate(); err != nil {
+		return err
+	}
+	r.set.Store(set)
+	return nil
+}
+
+// Load 解析 JSON 文档并替换当前的选择器，见 Parse
+func (r *Registry) Load(data []byte) error {
+	return r.LoadWith(data, json.Unmarshal)
+}
+
+// LoadWith 同 Load，使用 unmarshal 解析文档
+func (r *Registry) LoadWith(data []byte, unmarshal func([]byte, any) error) error {
+	set, err := ParseWith(data, unmarshal)
+	if err != nil {
+		return err
+	}
+	r.set.Store(set)
+	return nil
+}
+
+// Reset 恢复默认选择器
+func (r *Registry) Reset() {
+	r.set.Store(Default())
+}
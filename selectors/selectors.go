@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package selectors 教务处页面的 XPath 选择器和表格列映射
+// 教务处调整页面结构时，可以通过 JSON 覆盖默认值，不需要重新发布
+package selectors
+
+// Name 选择器名称，以页面为前缀
+type Name string
+
+// 通用的 ASP.NET 表单字段
+const (
+	ViewState          Name = "form.viewstate"
+	EventValidation    Name = "form.eventvalidation"
+	ViewStateGenerator Name = "form.viewstategenerator"
+)
+
+// 选课
+const (
+	CourseTerms   Name = "course.terms"   // 学期下拉框的选项
+	CourseTable   Name = "course.table"   // 选课结果表格
+	CourseLinks   Name = "course.links"   // 课程大纲/授课计划单元格中的链接，相对于单元格
+	CourseCredits Name = "course.credits" // 学分单元格中的学分，相对于单元格
+)
+
+// 成绩
+const (
+	MarksTable       Name = "marks.table"        // 成绩表格
+	MarksCredits     Name = "marks.credits"      // 计划学分单元格中的学分，相对于单元格
+	MarksScore       Name = "marks.score"        // 得分单元格中的成绩，相对于单元格
+	UnifiedExamTable Name = "unified_exam.table" // CET、省计算机成绩表格
+	UnifiedExamRows  Name = "unified_exam.rows"  // 成绩表格中的数据行，相对于表格
+)
+
+// 学分和绩点
+const (
+	CreditStatistics Name = "credit.statistics"  // 学分统计
+	CreditTables     Name = "credit.tables"      // 学分统计中的表格，相对于学分统计
+	GPATime          Name = "gpa.time"           // 绩点更新时间
+	GPATable         Name = "gpa.table"          // 绩点表格
+	GPAHeader        Name = "gpa.header"         // 绩点表格的表头，相对于表格
+	GPAHeaderCells   Name = "gpa.header_cells"   // 表头中的单元格，相对于表头
+	GPACells         Name = "gpa.cells"          // 绩点表格中的全部单元格，相对于表格
+	LectureTable     Name = "lecture.table"      // 讲座表格
+	ExamRoomTable    Name = "exam_room.table"    // 考场表格
+	RoomTypes        Name = "room.types"         // 教室类型下拉框的选项
+	RoomList         Name = "room.rooms"         // 空教室下拉框的选项
+	CalendarTerm     Name = "calendar.term"      // 校历中的当前学期
+	CalendarTerms    Name = "calendar.terms"     // 校历学期下拉框的选项
+	CalendarEvents   Name = "calendar.events"    // 校历中的学期安排
+	PlanColleges     Name = "plan.colleges"      // 培养方案的学院下拉框
+	PlanMajors       Name = "plan.majors"        // 培养方案的专业下拉框
+	PlanOptions      Name = "plan.options"       // 下拉框中的选项，相对于下拉框
+	NoticeList       Name = "notice.list"        // 通知列表
+	NoticeItems      Name = "notice.items"       // 通知列表中的条目，相对于列表
+	NoticeItemDate   Name = "notice.item_date"   // 条目中的日期，相对于条目
+	NoticeItemLink   Name = "notice.item_link"   // 条目中的链接，相对于条目
+	NoticeTotalPages Name = "notice.total_pages" // 总页数
+	NoticeDetail     Name = "notice.detail"      // 通知详情的主容器
+	NoticeTitle      Name = "notice.title"       // 通知标题，相对于主容器
+	NoticeTime       Name = "notice.time"        // 发布时间，相对于主容器
+	NoticeContent    Name = "notice.content"     // 通知正文，相对于主容器
+)
+
+// 个人信息
+const (
+	UserStudentID        Name = "user.student_id"
+	UserName             Name = "user.name"
+	UserBirthday         Name = "user.birthday"
+	UserSex              Name = "user.sex"
+	UserPhone            Name = "user.phone"
+	UserEmail            Name = "user.email"
+	UserCollege          Name = "user.college"
+	UserGrade            Name = "user.grade"
+	UserStatusChanges    Name = "user.status_changes"
+	UserMajor            Name = "user.major"
+	UserCounselor        Name = "user.counselor"
+	UserExamineeCategory Name = "user.examinee_category"
+	UserNationality      Name = "user.nationality"
+	UserCountry          Name = "user.country"
+	UserPoliticalStatus  Name = "user.political_status"
+	UserSource           Name = "user.source"
+)
+
+// Table 按表头定位列的表格名称
+type Table string
+
+const (
+	Marks       Table = "marks"        // 成绩
+	Courses     Table = "courses"      // 选课结果
+	Lectures    Table = "lectures"     // 讲座
+	ExamRooms   Table = "exam_room"    // 考场
+	UnifiedExam Table = "unified_exam" // CET、省计算机成绩
+)
+
+var defaultXPath = map[Name]string{
+	ViewState:          `//*[@id="__VIEWSTATE"]`,
+	EventValidation:    `//*[@id="__EVENTVALIDATION"]`,
+	ViewStateGenerator: `//*[@id="__VIEWSTATEGENERATOR"]`,
+
+	CourseTerms:   `//*[@id="ContentPlaceHolder1_DDL_xnxq"]/option/@value`,
+	CourseTable:   `//*[@id="ContentPlaceHolder1_DataList_xxk"]/tbody`,
+	CourseLinks:   `a`,
+	CourseCredits: `span`,
+
+	MarksTable:       `//*[@id="ContentPlaceHolder1_DataList_xxk"]/tbody`,
+	MarksCredits:     `span`,
+	MarksScore:       `font`,
+	UnifiedExamTable: `//*[@id="ContentPlaceHolder1_DataList_xxk"]`,
+	UnifiedExamRows:  `.//tr[@onmouseover]`,
+
+	CreditStatistics: `//*[@id="ContentPlaceHolder1_LB_kb"]`,
+	CreditTables:     `//table`,
+	GPATime:          `//*[@id="ContentPlaceHolder1_Label1"]`,
+	GPATable:         `//*[@id="ContentPlaceHolder1_DataList_xxk"]`,
+	GPAHeader:        `//tr[@style="height:30px; background:#efefef; border-bottom:1px solid gray; border-left:1px solid gray; vertical-align:middle;"]`,
+	GPAHeaderCells:   `./td[@align="center"]`,
+	GPACells:         `.//td[@align="center"]`,
+	LectureTable:     `//*[@id="ContentPlaceHolder1_DataList_xxk"]/tbody`,
+	ExamRoomTable:    `//*[@id="ContentPlaceHolder1_DataList_xxk"]`,
+	RoomTypes:        `//*[@id='jslxdpl']//option`,
+	RoomList:         `//*[@id='jsdpl']//option`,
+	CalendarTerm:     `//html/body/center/div`,
+	CalendarTerms:    `//select[@name="xq"]/option/@value`,
+	CalendarEvents:   `/html/body/table[2]/tbody/tr`,
+	PlanColleges:     `//select[@id="xymcdpl"]`,
+	PlanMajors:       `//select[@id="zymcdpl"]`,
+	PlanOptions:      `.//option`,
+	NoticeList:       `//div[@class='box-gl clearfix']`,
+	NoticeItems:      `.//ul[@class='list-gl']/li`,
+	NoticeItemDate:   `.//span[@class='doclist_time']`,
+	NoticeItemLink:   `.//a`,
+	NoticeTotalPages: `//span[@class='p_pages']//a[@href='jxtz/1.htm']`,
+	NoticeDetail:     `//div[contains(@class,'xl_main')]`,
+	NoticeTitle:      `.//*[contains(@class,'xl_tit')]/h4`,
+	NoticeTime:       `.//*[contains(@class,'xl_sj')]//span[1]`,
+	NoticeContent:    `.//*[@id='vsb_content']`,
+
+	UserStudentID:        `//*[@id="ContentPlaceHolder1_LB_xh"]`,
+	UserName:             `//*[@id="ContentPlaceHolder1_LB_xm"]`,
+	UserBirthday:         `//*[@id="ContentPlaceHolder1_LB_csrq"]`,
+	UserSex:              `//*[@id="ContentPlaceHolder1_LB_xb"]`,
+	UserPhone:            `//*[@id="ContentPlaceHolder1_LB_lxdh"]`,
+	UserEmail:            `//*[@id="ContentPlaceHolder1_LB_email"]`,
+	UserCollege:          `//*[@id="ContentPlaceHolder1_LB_xymc"]`,
+	UserGrade:            `//*[@id="ContentPlaceHolder1_LB_nj"]`,
+	UserStatusChanges:    `//*[@id="ContentPlaceHolder1_LB_xjxx"]`,
+	UserMajor:            `//*[@id="ContentPlaceHolder1_LB_zymc"]`,
+	UserCounselor:        `//*[@id="ContentPlaceHolder1_LB_zdy"]`,
+	UserExamineeCategory: `//*[@id="ContentPlaceHolder1_LB_kslb"]`,
+	UserNationality:      `//*[@id="ContentPlaceHolder1_LB_mz"]`,
+	UserCountry:          `//*[@id="ContentPlaceHolder1_LB_gb"]`,
+	UserPoliticalStatus:  `//*[@id="ContentPlaceHolder1_LB_zzmm"]`,
+	UserSource:           `//*[@id="ContentPlaceHolder1_LB_xssy"]`,
+}
+
+// defaultColumns 各表格中字段对应的表头文字，比较时忽略空白字符
+var defaultColumns = map[Table]map[string]string{
+	Marks: {
+		"type":           "修读类别",
+		"semester":       "开课学期",
+		"name":           "课程名称",
+		"credits":        "计划学分",
+		"score":          "得分",
+		"gpa":            "绩点",
+		"earned_credits": "获得学分",
+		"elective_type":  "选课类型",
+		"exam_type":      "考试类别",
+		"teacher":        "任课教师",
+		"classroom":      "上课时间地点",
+		"exam_time":      "考试时间地点",
+	},
+	Courses: {
+		"type":          "修读类别",
+		"name":          "课程名称",
+		"links":         "课程大纲/授课计划",
+		"credits":       "学分",
+		"elective_type": "选课类型",
+		"exam_type":     "考试类别",
+		"teacher":       "任课教师",
+		"schedule":      "上课时间地点",
+		"exam_time":     "考试时间地点",
+		"remark":        "备注",
+		"adjust":        "调课/停课信息",
+	},
+	Lectures: {
+		"category":          "讲座类别",
+		"issue_number":      "期号",
+		"title":             "讲座题目",
+		"speaker":           "主讲人",
+		"time":              "讲座时间",
+		"location":          "讲座地点",
+		"attendance_status": "听取讲座情况",
+	},
+	ExamRooms: {
+		"course_name": "课程名称",
+		"credit":      "学分",
+		"teacher":     "任课教师",
+		"exam_time":   "考试时间地点",
+	},
+	UnifiedExam: {
+		"name":  "考试名称",
+		"term":  "考试时间",
+		"score": "成绩",
+	},
+}
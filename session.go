@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+// SessionVersion 当前 Session 的序列化版本，结构发生不兼容的变化时递增
+const SessionVersion = 1
+
+// Session 可序列化的登录会话，用于在多个服务节点之间共享登录状态
+type Session struct {
+	Version    int             `json:"version"`    // 序列化版本
+	StudentID  string          `json:"student_id"` // 学号
+	Identifier string          `json:"identifier"` // 位于url上id=....的一个标识符
+	Cookies    []SessionCookie `json:"cookies"`    // 登录得到的 cookies
+	LoginAt    time.Time       `json:"login_at"`   // 登录时间
+}
+
+// SessionCookie 会话中的 cookie
+type SessionCookie struct {
+	Name     string    `json:"name"`
+	Value    string    `json:"value"`
+	Domain   string    `json:"domain,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Expires  time.Time `json:"expires,omitempty"` // 为零值时表示会话 cookie
+	Secure   bool      `json:"secure,omitempty"`
+	HttpOnly bool      `json:"http_only,omitempty"`
+}
+
+// Marshal 将会话序列化为 JSON
+func (sess *Session) Marshal() ([]byte, error) {
+	return json.Marshal(sess)
+}
+
+// UnmarshalSession 从 JSON 中恢复会话，版本不兼容时返回 ParamError
+func UnmarshalSession(data []byte) (*Session, error) {
+	sess := &Session{}
+	if err := json.Unmarshal(data, sess); err != nil {
+		return nil, errno.ParamError.WithErr(err)
+	}
+	if sess.Version != SessionVersion {
+		return nil, errno.ParamError.WithMessage(fmt.Sprintf("unsupported session version %d", sess.Version))
+	}
+	return sess, nil
+}
+
+// Expired 判断会话中是否有 cookie 已经过期
+func (sess *Session) Expired(now time.Time) bool {
+	for _, cookie := range sess.Cookies {
+		if !cookie.Expires.IsZero() && !cookie.Expires.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportSession 导出当前的登录会话
+func (s *Student) ExportSession() *Session {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// 教务处返回的 cookie 不带 Domain，导出时补上教务系统的主机名，便于其他 cookie 容器使用
+	domain := ""
+	if u, err := url.Parse(s.endpoints.JwchPrefix); err == nil {
+		domain = u.Hostname()
+	}
+
+	sess := &Session{
+		Version:    SessionVersion,
+		StudentID:  s.ID,
+		Identifier: s.Identifier,
+		Cookies:    make([]SessionCookie, 0, len(s.cookies)),
+		LoginAt:    s.loginAt,
+	}
+	for _, cookie := range s.cookies {
+		c := SessionCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Domain:   cookie.Domain,
+			Path:     cookie.Path,
+			Expires:  cookie.Expires,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+		}
+		if c.Domain == "" {
+			c.Domain = domain
+		}
+		sess.Cookies = append(sess.Cookies, c)
+	}
+	return sess
+}
+
+// ImportSession 将导出的会话恢复到 Student 上，之后无需登录即可查询
+// 如果 Student 已经设置了学号且与会话中的不一致，返回 AccountConflictError
+func (s *Student) ImportSession(sess *Session) error {
+	if sess == nil {
+		return errno.ParamError.WithMessage("session is nil")
+	}
+	if sess.Version != SessionVersion {
+		return errno.ParamError.WithMessage(fmt.Sprintf("unsupported session version %d", sess.Version))
+	}
+	if s.ID != "" && sess.StudentID != "" && s.ID != sess.StudentID {
+		return errno.AccountConflictError
+	}
+
+	cookies := make([]*http.Cookie, 0, len(sess.Cookies))
+	for _, c := range sess.Cookies {
+		cookies = append(cookies, &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		})
+	}
+
+	if s.ID == "" {
+		s.ID = sess.StudentID
+	}
+	s.ClearLoginData()
+	s.WithLoginData(sess.Identifier, cookies)
+	s.setLoginAt(sess.LoginAt)
+	return nil
+}
+
+func (s *Student) setLoginAt(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loginAt = t
+}
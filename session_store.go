@@ -0,0 +1,185 @@
+/*
+Copyright 2024 The west2-online Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jwch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/west2-online/jwch/errno"
+)
+
+// SessionStore 会话存储，按学号保存登录会话
+// 找不到会话时 Load 返回 errno.SessionNotFoundError
+type SessionStore interface {
+	Load(ctx context.Context, studentID string) (*Session, error)
+	Save(ctx context.Context, sess *Session) error
+	Delete(ctx context.Context, studentID string) error
+}
+
+// MemorySessionStore 基于内存的会话存储，进程退出后丢失，适合单机使用和测试
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string][]byte
+}
+
+// NewMemorySessionStore 创建基于内存的会话存储
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string][]byte)}
+}
+
+func (m *MemorySessionStore) Load(ctx context.Context, studentID string) (*Session, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	data, ok := m.sessions[studentID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, errno.SessionNotFoundError
+	}
+	// 保存的是序列化后的数据，避免调用方修改返回值影响存储的内容
+	return UnmarshalSession(data)
+}
+
+func (m *MemorySessionStore) Save(ctx context.Context, sess *Session) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	if sess == nil || sess.StudentID == "" {
+		return errno.ParamError.WithMessage("session without student id")
+	}
+
+	data, err := sess.Marshal()
+	if err != nil {
+		return errno.ParamError.WithErr(err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[sess.StudentID] = data
+	return nil
+}
+
+func (m *MemorySessionStore) Delete(ctx context.Context, studentID string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, studentID)
+	return nil
+}
+
+// FileSessionStore 基于文件的会话存储，每个学号对应 Dir 下的一个 JSON 文件
+type FileSessionStore struct {
+	Dir string // 存放会话文件的目录，不存在时会自动创建
+}
+
+// NewFileSessionStore 创建基于文件的会话存储
+func NewFileSessionStore(dir string) *FileSessionStore {
+	return &FileSessionStore{Dir: dir}
+}
+
+func (f *FileSessionStore) Load(ctx context.Context, studentID string) (*Session, error) {
+	if err := contextError(ctx); err != nil {
+		return nil, err
+	}
+
+	path, err := f.path(studentID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errno.SessionNotFoundError
+	}
+	if err != nil {
+		return nil, errno.ServiceInternalError.WithErr(err)
+	}
+	return UnmarshalSession(data)
+}
+
+func (f *FileSessionStore) Save(ctx context.Context, sess *Session) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+	if sess == nil {
+		return errno.ParamError.WithMessage("session is nil")
+	}
+
+	path, err := f.path(sess.StudentID)
+	if err != nil {
+		return err
+	}
+	data, err := sess.Marshal()
+	if err != nil {
+		return errno.ParamError.WithErr(err)
+	}
+	if err = os.MkdirAll(f.Dir, 0o700); err != nil {
+		return errno.ServiceInternalError.WithErr(err)
+	}
+
+	// 先写入临时文件再重命名，避免其他进程读到写了一半的文件
+	tmp, err := os.CreateTemp(f.Dir, ".session-*")
+	if err != nil {
+		return errno.ServiceInternalError.WithErr(err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return errno.ServiceInternalError.WithErr(err)
+	}
+	if err = tmp.Close(); err != nil {
+		return errno.ServiceInternalError.WithErr(err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return errno.ServiceInternalError.WithErr(err)
+	}
+	return nil
+}
+
+func (f *FileSessionStore) Delete(ctx context.Context, studentID string) error {
+	if err := contextError(ctx); err != nil {
+		return err
+	}
+
+	path, err := f.path(studentID)
+	if err != nil {
+		return err
+	}
+	if err = os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errno.ServiceInternalError.WithErr(err)
+	}
+	return nil
+}
+
+// path 返回学号对应的会话文件路径，学号中不允许出现路径分隔符
+func (f *FileSessionStore) path(studentID string) (string, error) {
+	if studentID == "" || strings.ContainsAny(studentID, `/\`) || studentID == "." || studentID == ".." {
+		return "", errno.ParamError.WithMessage("invalid student id: " + studentID)
+	}
+	return filepath.Join(f.Dir, studentID+".json"), nil
+}
@@ -17,12 +17,16 @@ limitations under the License.
 package jwch
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
-	"regexp"
+	"net/url"
+	"strings"
+	"time"
 
-	"github.com/west2-online/jwch/constants"
 	"github.com/west2-online/jwch/errno"
+	"github.com/west2-online/jwch/selectors"
 	"github.com/west2-online/jwch/utils"
 
 	"github.com/antchfx/htmlquery"
@@ -41,130 +45,265 @@ type verifyCodeResponse struct {
 
 // Login 模拟教务处登录/刷新Session
 func (s *Student) Login() error {
+	return s.LoginCtx(context.Background())
+}
+
+// LoginCtx 同 Login，ctx 被取消或超时时会中止登录流程
+func (s *Student) LoginCtx(ctx context.Context) (err error) {
+	ctx = ContextWithOperation(ctx, "Login")
+	defer s.finish(ctx, time.Now(), &err)
 	// 清除cookie
 	s.ClearLoginData()
 
-	code := verifyCodeResponse{}
 	loginResp := ssoLoginResponse{}
 	passMD5 := utils.Md5Hash(s.Password, 16)
-	// 获取验证码图片
-	resp, err := s.NewRequest().Get("https://jwcjwxt2.fzu.edu.cn:82/plus/verifycode.asp")
-	if err != nil {
-		return errno.HTTPQueryError.WithErr(err)
-	}
 
-	// 请求西二服务器，自动识别验证码
-	resp, err = s.NewRequest().SetFormData(map[string]string{
-		"validateCode": utils.Base64EncodeHTTPImage(resp.Body()),
-	}).Post(constants.AutoCaptchaVerifyURL)
-	if err != nil {
-		return errno.HTTPQueryError.WithMessage("automatic code identification failed")
+	// 验证码识别错误时，重新获取一张验证码再次尝试
+	attempts := max(s.captchaAttempts, 1)
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = s.loginCheck(ctx, passMD5)
+		if !errors.Is(err, errCaptchaRejected) {
+			break
+		}
+		s.logger.DebugContext(ctx, "jwch: captcha rejected", "student", s.ID, "attempt", attempt)
 	}
-
-	err = json.Unmarshal(resp.Body(), &code)
-	if err != nil {
-		return errno.HTTPQueryError.WithErr(err)
+	if errors.Is(err, errCaptchaRejected) {
+		return errno.WrongCaptchaError
 	}
-
-	// 登录验证
-	_, err = s.NewRequest().SetHeaders(map[string]string{
-		"Referer": "https://jwch.fzu.edu.cn",
-		"Origin":  "https://jwch.fzu.edu.cn",
-	}).SetFormData(map[string]string{
-		"Verifycode": code.Message,
-		"muser":      s.ID,
-		"passwd":     passMD5,
-	}).Post("https://jwcjwxt2.fzu.edu.cn:82/logincheck.asp")
-
-	// 由于禁用了302，这里正常情况下会返回一个错误，跳转链接中包含了我们要的全部信息
-	if err == nil {
-		return errno.LoginCheckFailedError
+	// 由于禁用了302，这里正常情况下会返回一个重定向，跳转链接中包含了我们要的全部信息
+	var redirect *loginRedirect
+	if !errors.As(err, &redirect) {
+		return err
 	}
-
-	// 获取token，第一个是匹配的全部字符，第二个是我们需要的
-	token := regexp.MustCompile(`token=(.*?)&`).FindStringSubmatch(err.Error())
-	if len(token) < 1 {
-		return errno.LoginCheckFailedError
+	params, err := redirectParams(redirect.location, "token", "id", "num")
+	if err != nil {
+		return err
 	}
 
-	id := regexp.MustCompile(`id=(.*?)&`).FindStringSubmatch(err.Error())[1]
-	num := regexp.MustCompile(`num=(.*?)&`).FindStringSubmatch(err.Error())[1]
-
 	// SSO登录
-	resp, err = s.NewRequest().SetHeaders(map[string]string{
+	resp, err := s.NewRequest().SetContext(ctx).SetHeaders(map[string]string{
 		"X-Requested-With": "XMLHttpRequest",
 	}).SetFormData(map[string]string{
-		"token": token[1],
-	}).Post(constants.SSOLoginURL)
+		"token": params.Get("token"),
+	}).Post(s.endpoints.SSOLoginURL)
 	if err != nil {
-		return errno.HTTPQueryError.WithErr(err)
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return ctxErr
+		}
+		return networkError(err)
 	}
 
 	err = json.Unmarshal(resp.Body(), &loginResp)
 	if err != nil {
+		// 维护期间返回的是 HTML 页面
+		if strings.Contains(string(resp.Body()), "维护") {
+			return errno.MaintenanceError
+		}
 		return errno.HTTPQueryError.WithErr(err)
 	}
 
 	// 获取account不存在是400，登录成功是200
 	if loginResp.Code != 200 {
-		return errno.SSOLoginFailedError
+		if e, ok := loginFailure(loginResp.Info); ok {
+			return e
+		}
+		return errno.SSOLoginFailedError.WithMessage("sso login failed: " + loginResp.Info)
 	}
 
-	// 获取cookies
-	resp, err = s.NewRequest().SetHeaders(map[string]string{
-		"Referer": constants.JwchReferer,
-		"Origin":  constants.JwchOrigin,
+	// 获取cookies，该请求会创建新的会话，不能重试
+	resp, err = s.NewRequest().SetContext(ContextWithoutRetry(ctx)).SetHeaders(map[string]string{
+		"Referer": s.endpoints.JwchReferer,
+		"Origin":  s.endpoints.JwchOrigin,
 	}).SetQueryParams(map[string]string{
-		"id":       id,
-		"num":      num,
-		"ssourl":   "https://jwcjwxt2.fzu.edu.cn",
-		"hosturl":  "https://jwcjwxt2.fzu.edu.cn:81",
+		"id":       params.Get("id"),
+		"num":      params.Get("num"),
+		"ssourl":   s.endpoints.SSOPrefix,
+		"hosturl":  s.endpoints.JwchPrefix,
 		"ssologin": "",
-	}).Get("https://jwcjwxt2.fzu.edu.cn:81/loginchk_xs.aspx")
+	}).Get(s.endpoints.LoginChkURL)
+
+	if ctxErr := contextError(ctx); ctxErr != nil {
+		return ctxErr
+	}
+	if resp == nil || resp.RawResponse == nil {
+		return networkError(err)
+	}
 
 	// 保存这部分Cookie，这部分Cookie是用来后续鉴权的[ASP.NET_SessionId]
 	s.SetCookies(resp.RawResponse.Cookies())
 
-	// 这里是err == nil 因为禁止了重定向，正常登录是会出现异常的
-	if err == nil {
+	// 正常登录会重定向到带有 id 的主页，没有重定向说明 SSO 签发的会话无效
+	if !isRedirect(resp.StatusCode()) {
 		return errno.CookieError
 	}
+	home, err := redirectParams(resp.Header().Get("Location"), "id")
+	if err != nil {
+		return err
+	}
 
-	data := regexp.MustCompile(`id=(.*?)&`).FindStringSubmatch(err.Error())
+	s.SetIdentifier(home.Get("id"))
+	s.setLoginAt(time.Now())
 
-	if len(data) < 1 {
-		return errno.CookieError
+	return nil
+}
+
+// loginRedirect logincheck 返回的重定向，重定向链接中包含后续登录需要的 token、id 和 num
+type loginRedirect struct {
+	location string
+}
+
+func (r *loginRedirect) Error() string {
+	return "login redirect"
+}
+
+// errCaptchaRejected 验证码识别错误，需要换一张验证码重试
+var errCaptchaRejected = errors.New("captcha rejected")
+
+// loginFailures 登录失败提示信息中的关键字，按顺序匹配
+var loginFailures = []struct {
+	keyword string
+	err     errno.ErrNo
+}{
+	{"维护", errno.MaintenanceError},
+	{"锁定", errno.AccountLockedError},
+	{"冻结", errno.AccountLockedError},
+	{"不存在", errno.AccountNotFoundError},
+	{"密码", errno.WrongPasswordError},
+}
+
+// loginFailure 根据提示信息判断登录失败的原因
+func loginFailure(message string) (errno.ErrNo, bool) {
+	for _, f := range loginFailures {
+		if strings.Contains(message, f.keyword) {
+			return f.err, true
+		}
 	}
+	return errno.ErrNo{}, false
+}
 
-	s.SetIdentifier(data[1])
+func isRedirect(code int) bool {
+	return code >= http.StatusMultipleChoices && code < http.StatusBadRequest
+}
 
-	return nil
+// redirectParams 解析重定向链接中的查询参数，缺少 keys 中的任意一个时返回 UnexpectedRedirectError
+// 错误信息中只包含链接的路径，不包含 token 等参数
+func redirectParams(location string, keys ...string) (url.Values, error) {
+	u, err := url.Parse(location)
+	if err != nil || location == "" {
+		return nil, errno.UnexpectedRedirectError
+	}
+	query := u.Query()
+	for _, key := range keys {
+		if query.Get(key) == "" {
+			return nil, errno.UnexpectedRedirectError.WithMessage("unexpected redirect to " + u.Host + u.Path)
+		}
+	}
+	return query, nil
+}
+
+// loginCheck 获取并识别验证码，然后提交账号密码进行登录验证
+// 验证通过时返回 *loginRedirect，验证码被拒绝时返回 errCaptchaRejected
+func (s *Student) loginCheck(ctx context.Context, passMD5 string) error {
+	// 获取验证码图片
+	resp, err := s.NewRequest().SetContext(ctx).Get(s.endpoints.VerifyCodeURL)
+	if err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return ctxErr
+		}
+		return networkError(err)
+	}
+
+	solver := s.captchaSolver
+	if solver == nil {
+		solver = &RemoteCaptchaSolver{URL: s.endpoints.AutoCaptchaVerifyURL, Client: s.client}
+	}
+	code, err := solver.Solve(ctx, resp.Body())
+	if err != nil {
+		return err
+	}
+
+	// 登录验证
+	resp, err = s.NewRequest().SetContext(ctx).SetHeaders(map[string]string{
+		"Referer": s.endpoints.noticeOrigin(),
+		"Origin":  s.endpoints.noticeOrigin(),
+	}).SetFormData(map[string]string{
+		"Verifycode": code,
+		"muser":      s.ID,
+		"passwd":     passMD5,
+	}).Post(s.endpoints.LoginCheckURL)
+	if ctxErr := contextError(ctx); ctxErr != nil {
+		return ctxErr
+	}
+	if resp == nil || resp.RawResponse == nil {
+		return networkError(err)
+	}
+	if isRedirect(resp.StatusCode()) {
+		return &loginRedirect{location: resp.Header().Get("Location")}
+	}
+
+	// 没有发生跳转说明登录验证失败，页面是提示信息（GB2312 页面已经在传输层转换为 UTF-8）
+	body := string(resp.Body())
+	message, alert := body, ""
+	if m := alertRegexp.FindStringSubmatch(body); m != nil {
+		message, alert = m[1], m[1]
+	}
+	if strings.Contains(message, "验证码") && !strings.Contains(message, "维护") {
+		return errCaptchaRejected
+	}
+	if e, ok := loginFailure(message); ok {
+		return e
+	}
+	if resp.StatusCode() >= http.StatusInternalServerError {
+		return errno.JwchNetworkError.WithMessage("logincheck returned " + resp.Status())
+	}
+	if alert != "" {
+		return errno.LoginCheckFailedError.WithMessage("login check failed: " + alert)
+	}
+	return errno.LoginCheckFailedError
 }
 
 // GetIdentifierAndCookies 方面服务端进行测试设置的接口
 func (s *Student) GetIdentifierAndCookies() (string, []*http.Cookie, error) {
-	err := s.CheckSession()
-	if err != nil {
-		if err := s.Login(); err != nil {
+	return s.GetIdentifierAndCookiesCtx(context.Background())
+}
+
+// GetIdentifierAndCookiesCtx 同 GetIdentifierAndCookies，支持通过 ctx 取消
+func (s *Student) GetIdentifierAndCookiesCtx(ctx context.Context) (_ string, _ []*http.Cookie, err error) {
+	ctx = ContextWithOperation(ctx, "GetIdentifierAndCookies")
+	defer s.finish(ctx, time.Now(), &err)
+	if err = s.CheckSessionCtx(ctx); err != nil {
+		if ctxErr := contextError(ctx); ctxErr != nil {
+			return "", nil, ctxErr
+		}
+		if err := s.LoginCtx(ctx); err != nil {
 			return "", nil, err
 		}
 	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.Identifier, s.client.Cookies, nil
 }
 
 // CheckSession returns not nil if SessionExpired or AccountConflict
 func (s *Student) CheckSession() error {
+	return s.CheckSessionCtx(context.Background())
+}
+
+// CheckSessionCtx 同 CheckSession，支持通过 ctx 取消
+func (s *Student) CheckSessionCtx(ctx context.Context) (err error) {
+	ctx = ContextWithOperation(ctx, "CheckSession")
+	defer s.finish(ctx, time.Now(), &err)
 	// 逻辑: 如果session没用，我们会返回一个302定向到https://jwcjwxt2.fzu.edu.cn:82/error.asp?id=300，但是我们禁用了重定向，意味着这里HTTP会抛出异常
 	// 旧版处理过程： 查询Body中是否含有[当前用户]这四个字
 
 	// 检查过期
-	resp, err := s.GetWithIdentifier(constants.UserInfoURL)
+	resp, err := s.GetWithIdentifierCtx(ctx, s.endpoints.UserInfoURL)
 	if err != nil {
 		return err
 	}
 
 	// 检查串号
-	res := htmlquery.FindOne(resp, `//*[@id="ContentPlaceHolder1_LB_xh"]`)
+	res := htmlquery.FindOne(resp, s.selectors.Current().XPath(selectors.UserStudentID))
 
 	if res == nil {
 		return errno.CookieError
@@ -179,28 +318,17 @@ func (s *Student) CheckSession() error {
 
 // GetInfo 获取学生个人信息
 func (s *Student) GetInfo() (resp *StudentDetail, err error) {
-	res, err := s.GetWithIdentifier(constants.UserInfoURL)
+	return s.GetInfoCtx(context.Background())
+}
+
+// GetInfoCtx 同 GetInfo，支持通过 ctx 取消
+func (s *Student) GetInfoCtx(ctx context.Context) (resp *StudentDetail, err error) {
+	ctx = ContextWithOperation(ctx, "GetInfo")
+	defer s.finish(ctx, time.Now(), &err)
+	res, err := s.GetWithIdentifierCtx(ctx, s.endpoints.UserInfoURL)
 	if err != nil {
 		return nil, err
 	}
 
-	resp = &StudentDetail{
-		Name:             safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_xm"]`),
-		Birthday:         safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_csrq"]`),
-		Sex:              safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_xb"]`),
-		Phone:            safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_lxdh"]`),
-		Email:            safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_email"]`),
-		College:          safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_xymc"]`),
-		Grade:            safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_nj"]`),
-		StatusChanges:    safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_xjxx"]`),
-		Major:            safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_zymc"]`),
-		Counselor:        safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_zdy"]`),
-		ExamineeCategory: safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_kslb"]`),
-		Nationality:      safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_mz"]`),
-		Country:          safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_gb"]`),
-		PoliticalStatus:  safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_zzmm"]`),
-		Source:           safeExtractHTMLFirst(res, `//*[@id="ContentPlaceHolder1_LB_xssy"]`),
-	}
-
-	return resp, nil
+	return s.parser().Info(res)
 }
@@ -157,6 +157,9 @@ func SafeAtoi(s string) int {
 	return n
 }
 
+// ConvertGB2312ToUTF8 将 GB2312（按 GB18030 解码）的内容转换为 UTF-8
+//
+// Deprecated: Student 收到的响应已经在传输层转换为 UTF-8，离线解析页面时使用 parse.Decode
 func ConvertGB2312ToUTF8(input []byte) (string, error) {
 	// 使用 transform.NewReader 进行编码转换
 	reader := transform.NewReader(bytes.NewReader(input), simplifiedchinese.GB18030.NewDecoder())